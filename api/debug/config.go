@@ -1,6 +1,11 @@
 package debug
 
+// Config : configuration of the debug endpoints
+// EnableLogLevelChange : serves PUT /debug/loglevel, the log levels are read-only without it
+// LogLevelToken : token required as "Authorization: Bearer <token>" by PUT /debug/loglevel, e.g. ${env:DEBUG_TOKEN}, empty relies on the network or a gateway
 type Config struct {
-	Enabled     bool `yaml:"Enabled"`
-	EnablePprof bool `yaml:"EnablePprof"`
+	Enabled              bool   `yaml:"Enabled"`
+	EnablePprof          bool   `yaml:"EnablePprof"`
+	EnableLogLevelChange bool   `yaml:"EnableLogLevelChange"`
+	LogLevelToken        string `yaml:"LogLevelToken" secret:"true"`
 }
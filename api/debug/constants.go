@@ -229,6 +229,16 @@ const (
                     Environment Variables
                 </h3>
                 <div id="env-data" class="data-section"></div>
+                
+                <h3 onclick="fetchData('config', '%s/debug/config')" class="clickable-item">
+                    Config Staleness
+                </h3>
+                <div id="config-data" class="data-section"></div>
+                
+                <h3 onclick="fetchData('loglevel', '%s/debug/loglevel')" class="clickable-item">
+                    Log Levels
+                </h3>
+                <div id="loglevel-data" class="data-section"></div>
             </div>
         </div>
 
@@ -2,24 +2,34 @@ package debug
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/gofreego/goutils/configutils/common"
 	"github.com/gofreego/goutils/logger"
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 )
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Version   string    `json:"version,omitempty"`
+	Status       string               `json:"status"`
+	Timestamp    time.Time            `json:"timestamp"`
+	Service      string               `json:"service"`
+	Version      string               `json:"version,omitempty"`
+	StaleConfigs []common.StaleConfig `json:"stale_configs,omitempty"`
+}
+
+// ConfigStatus represents the staleness of the configurations read from remote readers
+type ConfigStatus struct {
+	Stale        bool                 `json:"stale"`
+	StaleConfigs []common.StaleConfig `json:"stale_configs"`
 }
 
 // RuntimeInfo represents runtime information
@@ -60,6 +70,11 @@ func HealthHandler(serviceName string) http.HandlerFunc {
 			Service:   serviceName,
 			Version:   "1.0.0", // You can make this configurable
 		}
+		// the service keeps running on the last-known-good snapshots, report it as degraded
+		if stale := common.StaleConfigs(); len(stale) > 0 {
+			response.Status = "degraded"
+			response.StaleConfigs = stale
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -224,6 +239,89 @@ func EnvHandler() http.HandlerFunc {
 	}
 }
 
+// ConfigHandler provides the configurations served from local snapshots because their remote reader was unreachable
+func ConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stale := common.StaleConfigs()
+		status := ConfigStatus{Stale: len(stale) > 0, StaleConfigs: stale}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// LogLevelRequest represents the request to change the log levels at runtime
+// Level : level of the packages without an override, the current level is kept if empty
+// Packages : level overrides by package path, replaces the current overrides
+// TTL : duration after which the levels revert to the previous configuration, e.g. "10m", empty keeps the change
+type LogLevelRequest struct {
+	Level    logger.LogLevel            `json:"level"`
+	Packages map[string]logger.LogLevel `json:"packages"`
+	TTL      string                     `json:"ttl"`
+}
+
+// LogLevelHandler provides the log levels on GET and changes them on PUT
+// PUT is served only if cfg.EnableLogLevelChange is set, and requires the bearer token cfg.LogLevelToken if it is set.
+func LogLevelHandler(cfg *Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if !cfg.EnableLogLevelChange {
+				writeJSONError(w, http.StatusMethodNotAllowed, "log level changes are disabled, set EnableLogLevelChange in the debug config")
+				return
+			}
+			if !authorized(r, cfg.LogLevelToken) {
+				logger.Warn(r.Context(), "unauthorized log level change from %s", r.RemoteAddr)
+				writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			var req LogLevelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid request body, Err: "+err.Error())
+				return
+			}
+			var ttl time.Duration
+			if req.TTL != "" {
+				var err error
+				if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+					writeJSONError(w, http.StatusBadRequest, "invalid ttl "+req.TTL)
+					return
+				}
+			}
+			config := logger.LevelConfig{Level: req.Level, Packages: req.Packages}
+			if config.Level == "" {
+				config.Level = logger.GetLevels().Level
+			}
+			if err := logger.SetLevels(config, ttl); err != nil {
+				writeJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logger.Info(r.Context(), "log levels changed to %s, packages : %v, ttl : %s", config.Level, config.Packages, req.TTL)
+		default:
+			writeJSONError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+			return
+		}
+		json.NewEncoder(w).Encode(logger.GetLevels())
+	}
+}
+
+// authorized reports whether the request has the bearer token, any request is authorized without a token.
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
 // PProfIndexHandler provides a custom pprof index page
 func PProfIndexHandler(basePath string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -242,7 +340,7 @@ func DebugIndexHandler(serviceName, environment, basePath string, enablePprof bo
 			pprofSection = fmt.Sprintf(PProfSectionTemplate, basePath, basePath, basePath, basePath, basePath, basePath, basePath)
 		}
 
-		fmt.Fprintf(w, DebugIndexTemplate, serviceName, serviceName, environment, basePath, basePath, basePath, basePath, basePath, basePath, basePath, basePath, pprofSection)
+		fmt.Fprintf(w, DebugIndexTemplate, serviceName, serviceName, environment, basePath, basePath, basePath, basePath, basePath, basePath, basePath, basePath, basePath, basePath, pprofSection)
 	}
 }
 
@@ -262,6 +360,8 @@ func RegisterDebugHandlers(ctx context.Context, cfg *Config, mux *http.ServeMux,
 	mux.HandleFunc("/debug/memory", MemoryHandler())
 	mux.HandleFunc("/debug/vars", VarsHandler())
 	mux.HandleFunc("/debug/env", EnvHandler())
+	mux.HandleFunc("/debug/config", ConfigHandler())
+	mux.HandleFunc("/debug/loglevel", LogLevelHandler(cfg))
 
 	// Profiling endpoints (only if enabled)
 	if cfg.EnablePprof {
@@ -316,6 +416,17 @@ func RegisterDebugHandlersWithGateway(ctx context.Context, cfg *Config, mux *gwr
 	mux.HandlePath("GET", basePath+"/debug/env", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
 		EnvHandler()(w, r)
 	})
+	mux.HandlePath("GET", basePath+"/debug/config", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ConfigHandler()(w, r)
+	})
+	mux.HandlePath("GET", basePath+"/debug/loglevel", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		LogLevelHandler(cfg)(w, r)
+	})
+	if cfg.EnableLogLevelChange {
+		mux.HandlePath("PUT", basePath+"/debug/loglevel", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			LogLevelHandler(cfg)(w, r)
+		})
+	}
 
 	// Profiling endpoints (only if enabled)
 	if cfg.EnablePprof {
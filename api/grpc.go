@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/customerrors"
+	"github.com/gofreego/goutils/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor sets the request context and the trace context of the incoming metadata in the context of the request,
+// returns the request id in the x-request-id response header and logs the method, status code and latency of the request.
+// Panics are recovered into codes.Internal and *customerrors.Error into the grpc code of its status.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		ctx, requestID := serverContext(ctx)
+		if err := grpc.SetHeader(ctx, metadata.Pairs(constants.X_REQUEST_ID, requestID)); err != nil {
+			logger.Error(ctx, "Error setting request id header : %v", err)
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ctx, info.FullMethod, r)
+			}
+			// the error is logged with its cause, the client gets the status
+			logRequestEnd(ctx, "grpc", info.FullMethod, start, err)
+			err = grpcError(err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the UnaryServerInterceptor of the streams, the request is logged once the stream is closed.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx, requestID := serverContext(ss.Context())
+		if err := ss.SetHeader(metadata.Pairs(constants.X_REQUEST_ID, requestID)); err != nil {
+			logger.Error(ctx, "Error setting request id header : %v", err)
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ctx, info.FullMethod, r)
+			}
+			// the error is logged with its cause, the client gets the status
+			logRequestEnd(ctx, "grpc", info.FullMethod, start, err)
+			err = grpcError(err)
+		}()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UnaryClientInterceptor propagates the request id and the trace context of ctx in the outgoing metadata, the request id is generated without one,
+// and logs the method, status code and latency of the calls.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		ctx = clientContext(ctx)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logRequestEnd(ctx, "grpc client", method, start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor is the UnaryClientInterceptor of the streams, the call is logged once the stream ends.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		ctx = clientContext(ctx)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logRequestEnd(ctx, "grpc client", method, start, err)
+			return nil, err
+		}
+		stream := &clientStream{ClientStream: cs, ctx: ctx, method: method, start: start, serverStreams: desc.ServerStreams}
+		go stream.endOnDone()
+		return stream, nil
+	}
+}
+
+// serverContext returns ctx with the request context and the trace context of the incoming metadata, and the request id.
+func serverContext(ctx context.Context) (context.Context, string) {
+	rc := logger.NewGRPCRequestContext(ctx)
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = logger.ExtractTraceContext(ctx, metadataHeader(md))
+	return logger.WithRequestContext(ctx, rc), rc.RequestID
+}
+
+// clientContext returns ctx with the request id and the trace context in the outgoing metadata,
+// the request id is the one of the outgoing metadata, else of the request context, else a new one.
+func clientContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(constants.X_REQUEST_ID)) == 0 {
+		requestID := uuid.New().String()
+		if rc, ok := logger.RequestContextFromContext(ctx); ok && rc.RequestID != "" {
+			requestID = rc.RequestID
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, constants.X_REQUEST_ID, requestID)
+	}
+	header := http.Header{}
+	logger.InjectTraceContext(ctx, header)
+	for key, values := range header {
+		for _, value := range values {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+		}
+	}
+	return ctx
+}
+
+// metadataHeader returns the metadata as an http header, e.g. to read the traceparent header.
+func metadataHeader(md metadata.MD) http.Header {
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	return header
+}
+
+// recoverPanic logs the panic and returns the codes.Internal error of the request.
+// The stack trace of the log line, see logger.Config.StacktraceLevel, has the frame of the panic.
+func recoverPanic(ctx context.Context, method string, r any) error {
+	logger.Errorf(ctx, fmt.Sprintf("Error panic in grpc %s : %v", method, r), logger.NewFields())
+	return status.Error(codes.Internal, customerrors.ERROR_INTERNAL_SERVER_ERROR.Error())
+}
+
+// grpcError returns the grpc status error of err, the code of a *customerrors.Error is the grpc code of its http status
+// and the message its Message, the cause is not sent to the client.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var customErr *customerrors.Error
+	if errors.As(err, &customErr) {
+		return customErr.GRPCStatus().Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Convert(err).Err()
+}
+
+// logRequestEnd logs the method, status code and latency of a request, at the error level for the server errors and the warn level for the client errors.
+func logRequestEnd(ctx context.Context, kind string, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := logger.NewFields().AddField("code", code.String()).AddField("totalTime", time.Since(start).Milliseconds())
+	message := fmt.Sprintf("%s %s request end ", kind, method)
+	switch code {
+	case codes.OK:
+		logger.Infof(ctx, message, fields)
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		logger.Errorf(ctx, message, fields.Add(logger.Err(err)))
+	default:
+		logger.Warnf(ctx, message, fields.Add(logger.Err(err)))
+	}
+}
+
+// serverStream is a grpc.ServerStream with the context of the interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream logs the call once, when the stream ends with io.EOF or an error, when the response of a client streaming call is received,
+// or when the caller cancels the stream, e.g. a bidi stream the caller stopped reading.
+type clientStream struct {
+	grpc.ClientStream
+	ctx           context.Context
+	method        string
+	start         time.Time
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil && !s.serverStreams:
+		// the response is the only message of a client streaming call, CloseAndRecv does not wait for io.EOF
+		s.end(nil)
+	case errors.Is(err, io.EOF):
+		s.end(nil)
+	case err != nil:
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.end(err)
+	}
+	return md, err
+}
+
+// endOnDone logs the call when the caller cancels it, the context of the stream is done once the stream ends.
+// A stream ending on its own is logged by RecvMsg.
+func (s *clientStream) endOnDone() {
+	<-s.ClientStream.Context().Done()
+	if err := s.ctx.Err(); err != nil {
+		s.end(status.FromContextError(err).Err())
+	}
+}
+
+func (s *clientStream) end(err error) {
+	s.once.Do(func() {
+		logRequestEnd(s.ctx, "grpc client", s.method, s.start, err)
+	})
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/customerrors"
+	"github.com/gofreego/goutils/logger"
+	"github.com/gofreego/goutils/logger/logtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testServer implements the test.Test service, Unary calls unary and Collect counts the messages of the client.
+type testServer struct {
+	unary func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+var testServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Test",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(wrapperspb.StringValue)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return srv.(*testServer).unary(ctx, req.(*wrapperspb.StringValue))
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Test/Unary"}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Collect",
+		ClientStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			count := 0
+			for {
+				err := stream.RecvMsg(new(wrapperspb.StringValue))
+				if errors.Is(err, io.EOF) {
+					return stream.SendMsg(wrapperspb.String(strconv.Itoa(count)))
+				}
+				if err != nil {
+					return err
+				}
+				count++
+			}
+		},
+	}},
+}
+
+func newTestClient(t *testing.T, srv *testServer) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor()), grpc.StreamInterceptor(StreamServerInterceptor()))
+	server.RegisterService(&testServiceDesc, srv)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func invokeUnary(ctx context.Context, conn *grpc.ClientConn, opts ...grpc.CallOption) error {
+	return conn.Invoke(ctx, "/test.Test/Unary", wrapperspb.String("req"), new(wrapperspb.StringValue), opts...)
+}
+
+func TestGRPCPanic(t *testing.T) {
+	logs := logtest.Replace(t)
+	conn := newTestClient(t, &testServer{unary: func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		panic("nil map")
+	}})
+
+	err := invokeUnary(context.Background(), conn)
+	if st := status.Convert(err); st.Code() != codes.Internal || st.Message() != "internal server error" {
+		t.Fatalf("Invoke() = %v, want Internal internal server error", err)
+	}
+	logs.AssertLogged(t, logger.ErrorLevel, "Error panic in grpc /test.Test/Unary : nil map")
+}
+
+func TestGRPCCustomError(t *testing.T) {
+	logs := logtest.Replace(t)
+	cause := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{name: "custom", err: customerrors.New(http.StatusNotFound, "order not found"), code: codes.NotFound, message: "order not found"},
+		{name: "wrapped", err: customerrors.Wrap(cause, http.StatusInternalServerError, "failed to create order"), code: codes.Internal, message: "failed to create order"},
+		{name: "wrapping", err: fmt.Errorf("creating order: %w", customerrors.Wrap(cause, http.StatusConflict, "order exists")), code: codes.AlreadyExists, message: "order exists"},
+		{name: "status", err: status.Error(codes.Unavailable, "try later"), code: codes.Unavailable, message: "try later"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := newTestClient(t, &testServer{unary: func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+				return nil, test.err
+			}})
+			err := invokeUnary(context.Background(), conn)
+			if st := status.Convert(err); st.Code() != test.code || st.Message() != test.message {
+				t.Fatalf("Invoke() = %v, want %s %s", err, test.code, test.message)
+			}
+		})
+	}
+	// the server logs the cause
+	for _, entry := range logs.Filter(logger.ErrorLevel, "grpc /test.Test/Unary request end") {
+		if strings.Contains(fmt.Sprint(entry.Fields), "connection refused") {
+			return
+		}
+	}
+	t.Fatalf("the cause is not logged, the entries are %v", logs.Entries())
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	logtest.Replace(t)
+	var serverRequestID string
+	conn := newTestClient(t, &testServer{unary: func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		rc, _ := logger.RequestContextFromContext(ctx)
+		serverRequestID = rc.RequestID
+		return req, nil
+	}})
+
+	ctx := logger.WithRequestContext(context.Background(), logger.RequestContext{RequestID: "r1"})
+	var header metadata.MD
+	if err := invokeUnary(ctx, conn, grpc.Header(&header)); err != nil {
+		t.Fatalf("Invoke() = %v", err)
+	}
+	if got := header.Get(constants.X_REQUEST_ID); serverRequestID != "r1" || len(got) != 1 || got[0] != "r1" {
+		t.Fatalf("server request id = %q, response header = %q, want r1", serverRequestID, got)
+	}
+
+	// without a request id, the client generates one
+	if err := invokeUnary(context.Background(), conn, grpc.Header(&header)); err != nil {
+		t.Fatalf("Invoke() = %v", err)
+	}
+	if got := header.Get(constants.X_REQUEST_ID); serverRequestID == "" || len(got) != 1 || got[0] != serverRequestID {
+		t.Fatalf("server request id = %q, response header = %q, want the same id", serverRequestID, got)
+	}
+}
+
+func TestGRPCClientStreamLogged(t *testing.T) {
+	logs := logtest.Replace(t)
+	conn := newTestClient(t, &testServer{})
+	desc := &testServiceDesc.Streams[0]
+
+	stream, err := conn.NewStream(context.Background(), desc, "/test.Test/Collect")
+	if err != nil {
+		t.Fatalf("NewStream() = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stream.SendMsg(wrapperspb.String("item")); err != nil {
+			t.Fatalf("SendMsg() = %v", err)
+		}
+	}
+	// CloseAndRecv of the generated client streaming clients
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend() = %v", err)
+	}
+	reply := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(reply); err != nil || reply.Value != "2" {
+		t.Fatalf("RecvMsg() = %v, %v, want 2", reply, err)
+	}
+	if entries := logs.Filter(logger.InfoLevel, "grpc client /test.Test/Collect request end"); len(entries) != 1 {
+		t.Fatalf("client stream logged %d times, want once", len(entries))
+	}
+
+	// a stream cancelled by the caller is logged too
+	logs.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := conn.NewStream(ctx, desc, "/test.Test/Collect"); err != nil {
+		t.Fatalf("NewStream() = %v", err)
+	}
+	cancel()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if len(logs.Filter(logger.WarnLevel, "grpc client /test.Test/Collect request end")) > 0 {
+			return
+		}
+	}
+	t.Fatalf("cancelled client stream not logged, the entries are %v", logs.Entries())
+}
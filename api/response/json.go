@@ -18,7 +18,7 @@ type Response struct {
 func WriteErrorV2(ctx context.Context, w http.ResponseWriter, err error) {
 	errStr := "something went wrong"
 	if customErr, ok := err.(*customerrors.Error); ok {
-		errStr = customErr.Error()
+		errStr = customErr.Message()
 		w.WriteHeader(customErr.Code())
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -63,7 +63,7 @@ func WriteSuccessV2(ctx context.Context, w http.ResponseWriter, data any, status
 func WriteError(ctx *gin.Context, err error) {
 	errStr := "something went wrong"
 	if customErr, ok := err.(*customerrors.Error); ok {
-		errStr = customErr.Error()
+		errStr = customErr.Message()
 		ctx.JSON(customErr.Code(), &Response{Error: &errStr})
 		return
 	}
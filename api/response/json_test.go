@@ -0,0 +1,34 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofreego/goutils/customerrors"
+)
+
+func TestWriteErrorHidesCause(t *testing.T) {
+	err := customerrors.Wrap(errors.New("dial tcp 10.0.0.5:5432: connection refused"), http.StatusFailedDependency, "failed to create order")
+
+	recorder := httptest.NewRecorder()
+	WriteErrorV2(context.Background(), recorder, err)
+	if recorder.Code != http.StatusFailedDependency {
+		t.Fatalf("WriteErrorV2() status = %d, want %d", recorder.Code, http.StatusFailedDependency)
+	}
+	if body := recorder.Body.String(); body != `{"error":"failed to create order"}` {
+		t.Fatalf("WriteErrorV2() body = %s", body)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder = httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	WriteError(ctx, err)
+	if body := recorder.Body.String(); strings.Contains(body, "connection refused") || !strings.Contains(body, "failed to create order") {
+		t.Fatalf("WriteError() body = %s", body)
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gofreego/goutils/constants"
 	"github.com/gofreego/goutils/logger"
-	"github.com/google/uuid"
 )
 
 func GetHTTPRouter(mode string) *gin.Engine {
 	gin.SetMode(mode)
 	engine := gin.New()
+	// the values of the request context, e.g. the span context, are visible through the gin context passed to the logger
+	engine.ContextWithFallback = true
 	engine.Use(RequestTimeMiddleware)
 	engine.Use(RequestIDMiddleware)
 	engine.Use(OptionRequestMiddleware)
@@ -40,10 +41,15 @@ func RequestTimeMiddleware(c *gin.Context) {
 }
 
 func RequestIDMiddleware(c *gin.Context) {
-	// Set the request ID in the context
-	if c.GetHeader(constants.X_REQUEST_ID) != "" {
-		c.Set(constants.X_REQUEST_ID, uuid.New().String())
-	}
+	// Set the request context, read from the constants headers, in the context of the request where the logger reads it
+	rc := logger.NewHTTPRequestContext(c.Request)
+	rc.IP = c.ClientIP()
+	c.Set(constants.X_REQUEST_ID, rc.RequestID)
+	// Propagate the W3C traceparent header, the gin engine must have ContextWithFallback enabled for the logger to see the span context
+	ctx := logger.ExtractTraceContext(c.Request.Context(), c.Request.Header)
+	logger.InjectTraceContext(ctx, c.Writer.Header())
+	ctx = logger.WithRequestContext(ctx, rc)
+	c.Request = c.Request.WithContext(ctx)
 	// Pass control to the next middleware or route handler
 	c.Next()
 }
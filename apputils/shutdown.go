@@ -22,4 +22,6 @@ func GracefulShutdown(ctx context.Context, apps ...Application) {
 		app.Shutdown(ctx)
 		logger.Info(ctx, "%s is down", app.Name())
 	}
+	// flush the buffered entries of the log sinks, e.g. the entries waiting to be shipped to a collector
+	logger.Sync()
 }
@@ -15,7 +15,7 @@ type Cache struct {
 
 type Config struct {
 	Address  string        // Redis server address, e.g., "localhost:6379"
-	Password string        // Password for Redis server, if any
+	Password string        `secret:"true"` // Password for Redis server, if any
 	DB       int           // Redis database to connect to
 	PoolSize int           // Maximum number of connections in the pool
 	Timeout  time.Duration // Connection timeout duration
@@ -18,6 +18,8 @@ import (
 type Config struct {
 	Repository sql.Config      `yaml:"Repository" json:"repository"`
 	Migrator   migrator.Config `yaml:"Migrator" json:"migrator"`
+	// Logger : optional, e.g. an http sink shipping the logs of the job to a collector
+	Logger *logger.Config `yaml:"Logger" json:"logger"`
 }
 
 type SQLMigrator struct {
@@ -96,14 +98,36 @@ func main() {
 	if len(os.Args) > 1 {
 		configPath = os.Args[1]
 	}
-	var cfg Config
+	// print the json schema or a sample of the config, e.g. sql-migrator schema > migrator.schema.json
+	switch configPath {
+	case "schema":
+		printOrPanic(configutils.JSONSchema[Config]())
+		return
+	case "sample":
+		printOrPanic(configutils.SampleYAML[Config]())
+		return
+	}
 	ctx := context.Background()
-	err := configutils.ReadConfig(ctx, configPath, &cfg)
+	cfg, err := configutils.Load[Config](ctx, &configutils.Options{Path: configPath})
 	if err != nil {
 		panic("failed to read config, from " + configPath + ", err: " + err.Error())
 	}
-	app := NewSQLMigrator(&cfg)
+	if cfg.Logger != nil {
+		if err := cfg.Logger.InitiateLogger(); err != nil {
+			panic("failed to initiate logger, err: " + err.Error())
+		}
+	}
+	// the job exits without a shutdown signal, the shipped entries are flushed here
+	defer logger.Sync()
+	app := NewSQLMigrator(cfg)
 	if err := app.Run(ctx); err != nil {
 		panic("failed to run SQL migrator, err: " + err.Error())
 	}
 }
+
+func printOrPanic(bytes []byte, err error) {
+	if err != nil {
+		panic("failed to generate, err: " + err.Error())
+	}
+	fmt.Println(string(bytes))
+}
@@ -1,9 +1,38 @@
 package common
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 // ConfigFormatType represents the type of config format.
 type ConfigFormatType string
 
 const (
-	ConfigFormatJSON ConfigFormatType = "json"
-	ConfigFormatYAML ConfigFormatType = "yaml"
+	ConfigFormatJSON   ConfigFormatType = "json"
+	ConfigFormatYAML   ConfigFormatType = "yaml"
+	ConfigFormatTOML   ConfigFormatType = "toml"
+	ConfigFormatHCL    ConfigFormatType = "hcl"
+	ConfigFormatDotEnv ConfigFormatType = "dotenv"
 )
+
+// FormatFromPath detects the config format from the file extension of the path.
+// .json, .yaml, .yml, .toml, .hcl and .env (also .env.local like names) are supported.
+// It defaults to yaml if the extension is unknown.
+func FormatFromPath(path string) ConfigFormatType {
+	base := strings.ToLower(filepath.Base(path))
+	switch filepath.Ext(base) {
+	case ".json":
+		return ConfigFormatJSON
+	case ".toml":
+		return ConfigFormatTOML
+	case ".hcl":
+		return ConfigFormatHCL
+	case ".env":
+		return ConfigFormatDotEnv
+	}
+	if strings.HasPrefix(base, ".env") {
+		return ConfigFormatDotEnv
+	}
+	return ConfigFormatYAML
+}
@@ -0,0 +1,211 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofreego/goutils/configutils/internal/fields"
+)
+
+// maxDotEnvIndex is the maximum number of elements of a slice of structs read from indexed keys like SERVERS_0_HOST
+const maxDotEnvIndex = 10000
+
+// dotEnvKey returns the dotenv key of the field with the given yaml path, e.g. [Repository Postgres Host] -> REPOSITORY_POSTGRES_HOST
+func dotEnvKey(path []string) string {
+	return strings.ToUpper(strings.Join(path, "_"))
+}
+
+// parseDotEnv parses KEY=VALUE lines.
+// Empty lines and lines starting with # are skipped, an optional export prefix is allowed.
+// Values can be double quoted with escapes, single quoted as literals or unquoted with trailing " #" comments.
+func parseDotEnv(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid dotenv line %d, expected KEY=VALUE", lineNo)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value on dotenv line %d, Err: %w", lineNo, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, `'`) && strings.HasSuffix(value, `'`) && len(value) > 1:
+			value = value[1 : len(value)-1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// unmarshalDotEnv sets the fields of conf from the dotenv data.
+// conf can be a pointer to struct, where nested fields are named like REPOSITORY_POSTGRES_HOST from the yaml keys, or a pointer to map[string]string.
+// The elements of slices of structs are named with their index, e.g. SERVERS_0_HOST.
+func unmarshalDotEnv(data []byte, conf any) error {
+	values, err := parseDotEnv(data)
+	if err != nil {
+		return err
+	}
+	if m, ok := conf.(*map[string]string); ok {
+		if *m == nil {
+			*m = map[string]string{}
+		}
+		for key, value := range values {
+			(*m)[key] = value
+		}
+		return nil
+	}
+	if !fields.IsStructPointer(conf) {
+		return fmt.Errorf("config must be a non nil pointer to struct or map[string]string, got %T", conf)
+	}
+	if err := growDotEnvSlices(reflect.ValueOf(conf).Elem(), nil, values); err != nil {
+		return err
+	}
+	return fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		str, ok := values[dotEnvKey(path)]
+		if !ok || !fields.IsScalar(value.Type()) {
+			return nil
+		}
+		if err := fields.SetFromString(value, str); err != nil {
+			return fmt.Errorf("invalid value for %s, Err: %w", dotEnvKey(path), err)
+		}
+		return nil
+	})
+}
+
+// growDotEnvSlices sizes the slices of structs of v to the indexed keys, e.g. SERVERS_1_HOST makes Servers 2 long,
+// and allocates the nil pointers to structs with keys, so that fields.Walk visits their fields.
+func growDotEnvSlices(v reflect.Value, path []string, values map[string]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fields.Name(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), name)
+		prefix := dotEnvKey(fieldPath) + "_"
+		value := v.Field(i)
+		switch {
+		case value.Kind() == reflect.Struct:
+			if err := growDotEnvSlices(value, fieldPath, values); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Pointer && value.Type().Elem().Kind() == reflect.Struct:
+			if value.IsNil() {
+				if !hasDotEnvPrefix(values, prefix) {
+					continue
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			if err := growDotEnvSlices(value.Elem(), fieldPath, values); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			n := value.Len()
+			for key := range values {
+				rest, ok := strings.CutPrefix(key, prefix)
+				if !ok {
+					continue
+				}
+				index, _, _ := strings.Cut(rest, "_")
+				j, err := strconv.Atoi(index)
+				if err != nil || j < 0 {
+					continue
+				}
+				if j >= maxDotEnvIndex {
+					return fmt.Errorf("invalid index of %s, must be < %d", key, maxDotEnvIndex)
+				}
+				n = max(n, j+1)
+			}
+			if n > value.Len() {
+				slice := reflect.MakeSlice(value.Type(), n, n)
+				reflect.Copy(slice, value)
+				value.Set(slice)
+			}
+			for j := 0; j < n; j++ {
+				if err := growDotEnvSlices(value.Index(j), append(fieldPath, strconv.Itoa(j)), values); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasDotEnvPrefix(values map[string]string, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalDotEnv writes every non zero scalar field of conf as a KEY=VALUE line, sorted by key.
+// It fails on the non zero fields which cannot be read back, e.g. maps, since dotenv keys are flat and upper cased.
+func marshalDotEnv(conf any) ([]byte, error) {
+	values := map[string]string{}
+	if m, ok := conf.(map[string]string); ok {
+		values = m
+	} else {
+		v := reflect.ValueOf(conf)
+		if v.Kind() != reflect.Pointer {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			conf = ptr.Interface()
+		}
+		err := fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+			if value.IsZero() {
+				return nil
+			}
+			if !fields.IsScalar(value.Type()) {
+				return fmt.Errorf("dotenv does not support the %s field %s", value.Type(), dotEnvKey(path))
+			}
+			str := fields.String(value)
+			if value.Kind() == reflect.Slice {
+				items := make([]string, value.Len())
+				for i := range items {
+					items[i] = fields.String(value.Index(i))
+				}
+				str = strings.Join(items, ",")
+			}
+			values[dotEnvKey(path)] = str
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s=%s\n", key, strconv.Quote(values[key]))
+	}
+	return buf.Bytes(), nil
+}
@@ -1,11 +1,17 @@
 package common
 
 import (
+	"net/http"
+
 	"github.com/gofreego/goutils/customerrors"
 )
 
 var (
-	ErrConfigFormatNotSupported = customerrors.BAD_REQUEST_ERROR("config format not supported, Expect one of json, yaml")
+	ErrConfigFormatNotSupported = customerrors.BAD_REQUEST_ERROR("config format not supported, Expect one of json, yaml, toml, hcl, dotenv")
 	ErrInvalidConfigReaderName  = customerrors.BAD_REQUEST_ERROR("invalid config reader name, Expect one of consul, zookeeper, database, file")
 	ErrInvalidConfig            = customerrors.BAD_REQUEST_ERROR("invalid config")
+	ErrConfigNotFound           = customerrors.New(http.StatusNotFound, "config not found")
+	ErrVersionConflict          = customerrors.New(http.StatusConflict, "config version conflict, config is modified by someone else")
+	ErrVersionNotFound          = customerrors.New(http.StatusNotFound, "config version not found in history")
+	ErrNotConnected             = customerrors.New(http.StatusServiceUnavailable, "could not connect to the config store")
 )
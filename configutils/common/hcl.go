@@ -0,0 +1,71 @@
+package common
+
+import (
+	"reflect"
+
+	"github.com/gofreego/goutils/configutils/internal/fields"
+)
+
+// normalizeHCL unwraps the hcl blocks, which hcl decodes as lists of maps, where t expects a struct or a map.
+func normalizeHCL(value any, t reflect.Type) any {
+	if t == nil {
+		return value
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := unwrapHCLBlock(value)
+		if !ok {
+			return value
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fields.Name(field)
+			if v, found := m[name]; found {
+				m[name] = normalizeHCL(v, field.Type)
+			}
+		}
+		return m
+	case reflect.Map:
+		m, ok := unwrapHCLBlock(value)
+		if !ok {
+			return value
+		}
+		for key, v := range m {
+			m[key] = normalizeHCL(v, t.Elem())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		switch list := value.(type) {
+		case []map[string]any:
+			out := make([]any, len(list))
+			for i, v := range list {
+				out[i] = normalizeHCL(v, t.Elem())
+			}
+			return out
+		case []any:
+			for i, v := range list {
+				list[i] = normalizeHCL(v, t.Elem())
+			}
+			return list
+		}
+	}
+	return value
+}
+
+func unwrapHCLBlock(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case []map[string]any:
+		if len(v) == 1 {
+			return v[0], true
+		}
+	}
+	return nil, false
+}
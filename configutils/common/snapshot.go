@@ -0,0 +1,130 @@
+package common
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofreego/goutils/logger"
+)
+
+// Snapshots keeps the last-known-good configuration data read by a remote reader in local files,
+// so that the reader can fall back to them when the remote store is unreachable.
+// A nil *Snapshots keeps no snapshots.
+type Snapshots struct {
+	dir string
+}
+
+// NewSnapshots returns the snapshots of the reader kept in dir/name, nil if dir is empty.
+func NewSnapshots(dir string, name ConfigReaderName) *Snapshots {
+	if dir == "" {
+		return nil
+	}
+	return &Snapshots{dir: filepath.Join(dir, string(name))}
+}
+
+// file returns the snapshot file of the path, the path is escaped into a single file name.
+func (s *Snapshots) file(path string) string {
+	return filepath.Join(s.dir, url.PathEscape(path))
+}
+
+// Save writes the data read from path as its last-known-good snapshot and clears the staleness of path.
+// Errors are logged, a failed snapshot does not fail the read.
+func (s *Snapshots) Save(ctx context.Context, path string, data []byte) {
+	if s == nil {
+		return
+	}
+	markFresh(s.file(path))
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		logger.Error(ctx, "Error creating config snapshot dir : %s, err : %v", s.dir, err)
+		return
+	}
+	// the snapshot is written to a temporary file and renamed, so that a crash never leaves a partial snapshot
+	tmp, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		logger.Error(ctx, "Error creating config snapshot for path : %s, err : %v", path, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		logger.Error(ctx, "Error writing config snapshot for path : %s, err : %v", path, err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		logger.Error(ctx, "Error writing config snapshot for path : %s, err : %v", path, err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), s.file(path)); err != nil {
+		logger.Error(ctx, "Error saving config snapshot for path : %s, err : %v", path, err)
+	}
+}
+
+// Load returns the last-known-good snapshot of path after the remote read failed with cause.
+// It logs a staleness warning and marks path as stale, see StaleConfigs.
+// It returns cause if there is no snapshot.
+func (s *Snapshots) Load(ctx context.Context, path string, cause error) ([]byte, error) {
+	if s == nil {
+		return nil, cause
+	}
+	file := s.file(path)
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, cause
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		logger.Error(ctx, "Error reading config snapshot for path : %s, err : %v", path, err)
+		return nil, cause
+	}
+	logger.Warn(ctx, "remote config read failed for path : %s, using snapshot from %s (%s old), err : %v",
+		path, info.ModTime().Format(time.RFC3339), time.Since(info.ModTime()).Round(time.Second), cause)
+	markStale(file, StaleConfig{Path: path, SnapshotTime: info.ModTime(), Error: cause.Error()})
+	return data, nil
+}
+
+// StaleConfig describes a configuration served from its snapshot because the remote store was unreachable.
+type StaleConfig struct {
+	Path         string    `json:"path"`
+	SnapshotTime time.Time `json:"snapshot_time"`
+	StaleSince   time.Time `json:"stale_since"`
+	Error        string    `json:"error"`
+}
+
+var (
+	staleLock    sync.Mutex
+	staleConfigs = map[string]StaleConfig{}
+)
+
+func markStale(file string, stale StaleConfig) {
+	staleLock.Lock()
+	defer staleLock.Unlock()
+	stale.StaleSince = time.Now()
+	if current, ok := staleConfigs[file]; ok {
+		stale.StaleSince = current.StaleSince
+	}
+	staleConfigs[file] = stale
+}
+
+func markFresh(file string) {
+	staleLock.Lock()
+	defer staleLock.Unlock()
+	delete(staleConfigs, file)
+}
+
+// StaleConfigs returns the configurations currently served from snapshots, sorted by path.
+// A configuration is fresh again after the next successful remote read.
+func StaleConfigs() []StaleConfig {
+	staleLock.Lock()
+	defer staleLock.Unlock()
+	list := make([]StaleConfig, 0, len(staleConfigs))
+	for _, stale := range staleConfigs {
+		list = append(list, stale)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
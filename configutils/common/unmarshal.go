@@ -2,12 +2,16 @@ package common
 
 import (
 	"encoding/json"
+	"reflect"
 
-	"gopkg.in/yaml.v2"
+	"github.com/hashicorp/hcl"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
 // Unmarshal unmarshals the data into the given config object.
-// It supports json and yaml formats.
+// It supports json, yaml, toml, hcl and dotenv formats.
+// toml and hcl keys are matched with the yaml keys of the config object.
 // If no format is provided, it defaults to yaml.
 func Unmarshal(data []byte, conf any, cft ...ConfigFormatType) error {
 	t := ConfigFormatYAML
@@ -20,10 +24,44 @@ func Unmarshal(data []byte, conf any, cft ...ConfigFormatType) error {
 		return json.Unmarshal(data, conf)
 	case ConfigFormatYAML:
 		return yaml.Unmarshal(data, conf)
+	case ConfigFormatTOML:
+		var m map[string]any
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		return convertViaYAML(m, conf)
+	case ConfigFormatHCL:
+		var m map[string]any
+		if err := hcl.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		return convertViaYAML(normalizeHCL(m, reflect.TypeOf(conf)), conf)
+	case ConfigFormatDotEnv:
+		return unmarshalDotEnv(data, conf)
 	}
 	return ErrConfigFormatNotSupported
 }
 
+// UnmarshalCopy unmarshals the data into a copy of the value conf points to and sets it only if the unmarshal succeeds,
+// so that invalid data leaves conf unchanged. The fields already set in conf are kept unless the data sets them.
+func UnmarshalCopy(data []byte, conf any, cft ...ConfigFormatType) error {
+	v := reflect.ValueOf(conf)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return Unmarshal(data, conf, cft...)
+	}
+	copied := reflect.New(v.Elem().Type())
+	copied.Elem().Set(v.Elem())
+	if err := Unmarshal(data, copied.Interface(), cft...); err != nil {
+		return err
+	}
+	v.Elem().Set(copied.Elem())
+	return nil
+}
+
+// Marshal marshals the config object in the given format.
+// It supports json, yaml, toml, hcl and dotenv formats.
+// toml and hcl keys are the yaml keys of the config object, hcl is written as json which is valid hcl.
+// If no format is provided, it defaults to yaml.
 func Marshal(conf any, cft ...ConfigFormatType) ([]byte, error) {
 	t := ConfigFormatYAML
 	if len(cft) > 0 {
@@ -35,6 +73,29 @@ func Marshal(conf any, cft ...ConfigFormatType) ([]byte, error) {
 		return json.Marshal(conf)
 	case ConfigFormatYAML:
 		return yaml.Marshal(conf)
+	case ConfigFormatTOML:
+		var m map[string]any
+		if err := convertViaYAML(conf, &m); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(m)
+	case ConfigFormatHCL:
+		var m map[string]any
+		if err := convertViaYAML(conf, &m); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(m, "", "  ")
+	case ConfigFormatDotEnv:
+		return marshalDotEnv(conf)
 	}
 	return nil, ErrConfigFormatNotSupported
 }
+
+// convertViaYAML converts in to out through yaml, so yaml keys and value formats like durations apply to every format.
+func convertViaYAML(in any, out any) error {
+	bytes, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(bytes, out)
+}
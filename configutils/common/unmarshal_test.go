@@ -0,0 +1,90 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripServer struct {
+	Host   string  `yaml:"Host"`
+	Weight float64 `yaml:"Weight"`
+}
+
+type roundTripConfig struct {
+	Name    string            `yaml:"Name"`
+	Port    int               `yaml:"Port"`
+	Timeout time.Duration     `yaml:"Timeout"`
+	Debug   bool              `yaml:"Debug"`
+	Tags    []string          `yaml:"Tags"`
+	Primary roundTripServer   `yaml:"Primary"`
+	Servers []roundTripServer `yaml:"Servers"`
+	Backup  *roundTripServer  `yaml:"Backup"`
+	Labels  map[string]string `yaml:"Labels"`
+}
+
+func newRoundTripConfig() *roundTripConfig {
+	return &roundTripConfig{
+		Name:    "orders",
+		Port:    8080,
+		Timeout: 1500 * time.Millisecond,
+		Debug:   true,
+		Tags:    []string{"a", "b"},
+		Primary: roundTripServer{Host: "db-0", Weight: 0.5},
+		Servers: []roundTripServer{{Host: "db-1", Weight: 1}, {Host: "db-2"}},
+		Backup:  &roundTripServer{Host: "db-3"},
+		Labels:  map[string]string{"team": "payments"},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []ConfigFormatType{ConfigFormatJSON, ConfigFormatYAML, ConfigFormatTOML, ConfigFormatHCL, ConfigFormatDotEnv} {
+		t.Run(string(format), func(t *testing.T) {
+			want := newRoundTripConfig()
+			if format == ConfigFormatDotEnv {
+				want.Labels = nil
+			}
+			data, err := Marshal(want, format)
+			if err != nil {
+				t.Fatalf("Marshal() = %v", err)
+			}
+			got := &roundTripConfig{}
+			if err := Unmarshal(data, got, format); err != nil {
+				t.Fatalf("Unmarshal(%s) = %v", data, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Unmarshal(Marshal()) = %+v, want %+v\n%s", got, want, data)
+			}
+		})
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	if _, err := Marshal(newRoundTripConfig(), ConfigFormatDotEnv); err == nil || !strings.Contains(err.Error(), "LABELS") {
+		t.Fatalf("Marshal() of a map field = %v, want an error", err)
+	}
+
+	data := []byte(`
+# comment
+export NAME=orders # trailing comment
+PORT='8080'
+SERVERS_1_HOST="db-\"1\""
+SERVERS_0_WEIGHT=2
+`)
+	var conf roundTripConfig
+	if err := Unmarshal(data, &conf, ConfigFormatDotEnv); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	want := roundTripConfig{Name: "orders", Port: 8080, Servers: []roundTripServer{{Weight: 2}, {Host: `db-"1"`}}}
+	if !reflect.DeepEqual(conf, want) {
+		t.Fatalf("Unmarshal() = %+v, want %+v", conf, want)
+	}
+
+	if err := Unmarshal([]byte("SERVERS_100000_HOST=x"), &roundTripConfig{}, ConfigFormatDotEnv); err == nil {
+		t.Fatal("Unmarshal() with a huge index = nil, want an error")
+	}
+	if err := Unmarshal([]byte("PORT=abc"), &roundTripConfig{}, ConfigFormatDotEnv); err == nil {
+		t.Fatal("Unmarshal() with an invalid int = nil, want an error")
+	}
+}
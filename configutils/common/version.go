@@ -0,0 +1,20 @@
+package common
+
+import "fmt"
+
+// Version represents the version of a configuration in the configuration store.
+// It is the ModifyIndex for consul and the node version plus one for zookeeper, 0 is the version of a missing configuration.
+type Version uint64
+
+// HistoryEntry represents a previous value of a configuration.
+// Version : version of the configuration when it was replaced
+// Value : raw configuration data of that version
+type HistoryEntry struct {
+	Version Version `json:"version"`
+	Value   []byte  `json:"value"`
+}
+
+// HistoryKey returns the key a history entry is stored with, zero padded so that keys sort by version.
+func HistoryKey(version Version) string {
+	return fmt.Sprintf("%020d", version)
+}
@@ -0,0 +1,11 @@
+// Package defaults sets the default tags of the config structs.
+// It only depends on the standard library, so that the database clients and the config readers can use it without configutils.
+package defaults
+
+import "github.com/gofreego/goutils/configutils/internal/fields"
+
+// Apply sets the value of the default tag on every zero valued field of conf, a pointer to a struct.
+// returns an error if a default tag cannot be parsed into the type of its field
+func Apply(conf any) error {
+	return fields.ApplyDefaults(conf, nil)
+}
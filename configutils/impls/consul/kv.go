@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofreego/goutils/logger"
+	"github.com/hashicorp/consul/api"
+)
+
+// queryOptions returns the query options for the reads of the configuration, as per the consistency mode.
+func (a *ConsulConfigReader) queryOptions(ctx context.Context) *api.QueryOptions {
+	opts := &api.QueryOptions{}
+	switch a.cfg.Consistency {
+	case ConsistencyStale:
+		opts.AllowStale = true
+	case ConsistencyConsistent:
+		opts.RequireConsistent = true
+	}
+	return opts.WithContext(ctx)
+}
+
+// consistentQueryOptions returns the query options for the reads before a write, they are always consistent.
+func consistentQueryOptions(ctx context.Context) *api.QueryOptions {
+	return (&api.QueryOptions{RequireConsistent: true}).WithContext(ctx)
+}
+
+// get returns the key, nil if it does not exist.
+func (a *ConsulConfigReader) get(ctx context.Context, path string, opts *api.QueryOptions) (*api.KVPair, error) {
+	var pair *api.KVPair
+	err := a.retry(ctx, "get", func() error {
+		var err error
+		pair, _, err = a.kv.Get(path, opts)
+		return err
+	})
+	return pair, err
+}
+
+func (a *ConsulConfigReader) put(ctx context.Context, pair *api.KVPair) error {
+	return a.retry(ctx, "put", func() error {
+		_, err := a.kv.Put(pair, (&api.WriteOptions{}).WithContext(ctx))
+		return err
+	})
+}
+
+// cas is not retried, a retry after a lost response would report a conflict for its own write.
+func (a *ConsulConfigReader) cas(ctx context.Context, pair *api.KVPair) (bool, error) {
+	ok, _, err := a.kv.CAS(pair, (&api.WriteOptions{}).WithContext(ctx))
+	return ok, err
+}
+
+func (a *ConsulConfigReader) delete(ctx context.Context, key string) error {
+	return a.retry(ctx, "delete", func() error {
+		_, err := a.kv.Delete(key, (&api.WriteOptions{}).WithContext(ctx))
+		return err
+	})
+}
+
+func (a *ConsulConfigReader) keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := a.retry(ctx, "keys", func() error {
+		var err error
+		keys, _, err = a.kv.Keys(prefix, "", consistentQueryOptions(ctx))
+		return err
+	})
+	return keys, err
+}
+
+func (a *ConsulConfigReader) list(ctx context.Context, prefix string) (api.KVPairs, error) {
+	var pairs api.KVPairs
+	err := a.retry(ctx, "list", func() error {
+		var err error
+		pairs, _, err = a.kv.List(prefix, a.queryOptions(ctx))
+		return err
+	})
+	return pairs, err
+}
+
+// retry calls fn until it succeeds, fails with a non transient error or the retries are exhausted.
+// the backoff starts at RetryBackoff and doubles after every attempt.
+func (a *ConsulConfigReader) retry(ctx context.Context, op string, fn func() error) error {
+	backoff := a.cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) || attempt > a.cfg.MaxRetries {
+			return err
+		}
+		logger.Warn(ctx, "consul %s failed, attempt : %d, retrying in %s, err : %v", op, attempt, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// isUnavailable reports whether consul could not be reached, the reads fall back to the snapshots on these errors only.
+func isUnavailable(err error) bool {
+	return isTransient(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isTransient reports whether the error is worth a retry, network errors, 429 and 5xx responses.
+func isTransient(err error) bool {
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
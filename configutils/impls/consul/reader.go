@@ -2,38 +2,114 @@ package consul
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/configutils/defaults"
 	"github.com/gofreego/goutils/logger"
 	"github.com/hashicorp/consul/api"
 )
 
+const (
+	// historySuffix is appended to the config key to get the prefix of the history keys
+	historySuffix = ".history/"
+
+	// ConsistencyDefault lets the leader serve the reads without a consistency check
+	ConsistencyDefault = "default"
+	// ConsistencyStale lets any server serve the reads, possibly stale, it scales reads and survives leader loss
+	ConsistencyStale = "stale"
+	// ConsistencyConsistent makes the leader verify its leadership before serving the reads
+	ConsistencyConsistent = "consistent"
+
+	// watchWaitTime is the maximum duration of a blocking query
+	watchWaitTime = 5 * time.Minute
+)
+
 // Config : configuration for consul
 // Address : address of the consul server
+// Scheme : http or https, default http
 // Token : token for authentication
+// Datacenter : datacenter to read the configuration from, default is the datacenter of the agent
+// Namespace : namespace of the configuration, consul enterprise only
+// Partition : admin partition of the configuration, consul enterprise only
 // Path : path in consul to read the configuration from
+// Consistency : consistency mode of the reads, one of default, stale, consistent
+// TLS : tls configuration for https
+// MaxRetries : number of retries on transient errors, default 3, negative disables the retries
+// RetryBackoff : backoff before the first retry, doubled after every retry, default 200ms
+// HistorySize : number of previous values kept for rollback, default 10, negative disables the history
+// SnapshotDir : directory to keep the last-known-good configurations in, Read falls back to them when consul is unreachable, empty disables the snapshots
 type Config struct {
-	Address string `yaml:"Address"`
-	Token   string `yaml:"Token"`
-	Path    string `yaml:"Path"`
+	Address      string        `yaml:"Address"`
+	Scheme       string        `yaml:"Scheme" choices:"http,https"`
+	Token        string        `yaml:"Token" secret:"true"`
+	Datacenter   string        `yaml:"Datacenter"`
+	Namespace    string        `yaml:"Namespace"`
+	Partition    string        `yaml:"Partition"`
+	Path         string        `yaml:"Path"`
+	Consistency  string        `yaml:"Consistency" choices:"default,stale,consistent"`
+	TLS          TLSConfig     `yaml:"TLS"`
+	MaxRetries   int           `yaml:"MaxRetries" default:"3"`
+	RetryBackoff time.Duration `yaml:"RetryBackoff" default:"200ms"`
+	HistorySize  int           `yaml:"HistorySize" default:"10"`
+	SnapshotDir  string        `yaml:"SnapshotDir"`
+}
+
+// TLSConfig : tls configuration for consul
+// CAFile : path of the ca certificate to verify the server certificate
+// CertFile : path of the client certificate
+// KeyFile : path of the client key
+// ServerName : server name to verify the server certificate against, default is the host of the address
+// InsecureSkipVerify : skips the verification of the server certificate, do not use in production
+type TLSConfig struct {
+	CAFile             string `yaml:"CAFile"`
+	CertFile           string `yaml:"CertFile"`
+	KeyFile            string `yaml:"KeyFile"`
+	ServerName         string `yaml:"ServerName"`
+	InsecureSkipVerify bool   `yaml:"InsecureSkipVerify"`
+}
+
+// WithDefaults sets the default tags on the zero valued fields.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	return defaults.Apply(c)
 }
 
 type ConsulConfigReader struct {
-	kv  *api.KV
-	cfg *Config
+	kv        *api.KV
+	cfg       *Config
+	snapshots *common.Snapshots
 }
 
 // NewConsulConfigReader creates a new consul configuration reader
 func NewConsulConfigReader(ctx context.Context, config *Config) (*ConsulConfigReader, error) {
+	if err := config.WithDefaults(); err != nil {
+		logger.Error(ctx, "Error applying consul config defaults : %v", err)
+		return nil, err
+	}
 	client, err := api.NewClient(&api.Config{
-		Address: config.Address,
-		Token:   config.Token,
+		Address:    config.Address,
+		Scheme:     config.Scheme,
+		Token:      config.Token,
+		Datacenter: config.Datacenter,
+		Namespace:  config.Namespace,
+		Partition:  config.Partition,
+		TLSConfig: api.TLSConfig{
+			Address:            config.TLS.ServerName,
+			CAFile:             config.TLS.CAFile,
+			CertFile:           config.TLS.CertFile,
+			KeyFile:            config.TLS.KeyFile,
+			InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+		},
 	})
 	if err != nil {
 		logger.Error(ctx, "Error creating consul client : %v", err)
 		return nil, err
 	}
-	return &ConsulConfigReader{kv: client.KV(), cfg: config}, nil
+	return &ConsulConfigReader{kv: client.KV(), cfg: config, snapshots: common.NewSnapshots(config.SnapshotDir, common.ConsulConfigReader)}, nil
 }
 
 // Read reads the configuration from consul
@@ -42,27 +118,47 @@ func NewConsulConfigReader(ctx context.Context, config *Config) (*ConsulConfigRe
 // configFormat : format of the configuration data
 // returns error if any
 // returns nil if successful
+// If consul is unreachable and SnapshotDir is set, the configuration is read from the last-known-good snapshot instead.
+// Invalid data read from consul is returned as an error, it is not replaced by the snapshot.
 func (a *ConsulConfigReader) Read(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	_, err := a.ReadVersion(ctx, path, conf, configFormat...)
+	if err == nil || !isUnavailable(err) {
+		return err
+	}
+	data, snapshotErr := a.snapshots.Load(ctx, a.cfg.Path+path, err)
+	if snapshotErr != nil {
+		return snapshotErr
+	}
+	return common.UnmarshalCopy(data, conf, configFormat...)
+}
+
+// ReadVersion reads the configuration from consul like Read and returns its version, the ModifyIndex of the key.
+// conf is left unchanged if the data cannot be unmarshalled.
+// returns common.ErrConfigNotFound if the key does not exist
+func (a *ConsulConfigReader) ReadVersion(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) (common.Version, error) {
 	path = a.cfg.Path + path
 	logger.Debug(ctx, "reading from consul path : %s", path)
-	data, _, err := a.kv.Get(path, nil)
+	data, err := a.get(ctx, path, a.queryOptions(ctx))
 	if err != nil {
 		logger.Error(ctx, "Error reading from consul : %v", err)
-		return err
+		return 0, err
 	}
 	if data == nil {
-		return nil
+		logger.Error(ctx, "config not found in consul path : %s", path)
+		return 0, common.ErrConfigNotFound
 	}
 
-	err = common.Unmarshal(data.Value, conf, configFormat...)
+	err = common.UnmarshalCopy(data.Value, conf, configFormat...)
 	if err != nil {
 		logger.Error(ctx, "Error unmarshalling yaml for path: %s, data : %v", path, err)
-		return err
+		return 0, err
 	}
-	return nil
+	a.snapshots.Save(ctx, path, data.Value)
+	return common.Version(data.ModifyIndex), nil
 }
 
 // Update updates the configuration in consul
+// The previous value is kept in the history, the update fails with common.ErrVersionConflict if the key is modified concurrently.
 // path : path in consul to update the configuration
 // conf : configuration object to marshal the data from
 // configFormat : format of the configuration data
@@ -70,20 +166,195 @@ func (a *ConsulConfigReader) Read(ctx context.Context, path string, conf any, co
 // returns nil if successful
 func (a *ConsulConfigReader) Update(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
 	path = a.cfg.Path + path
-	logger.Debug(ctx, "updating consul path : %s", path)
+	current, err := a.get(ctx, path, consistentQueryOptions(ctx))
+	if err != nil {
+		logger.Error(ctx, "Error reading from consul : %v", err)
+		return err
+	}
+	var version common.Version
+	if current != nil {
+		version = common.Version(current.ModifyIndex)
+	}
+	_, err = a.update(ctx, path, conf, version, configFormat...)
+	return err
+}
+
+// UpdateVersion updates the configuration in consul only if its current version is the given version, check-and-set on the ModifyIndex.
+// version 0 creates the key only if it does not exist.
+// returns the new version, common.ErrVersionConflict if the version does not match
+func (a *ConsulConfigReader) UpdateVersion(ctx context.Context, path string, conf any, version common.Version, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	return a.update(ctx, a.cfg.Path+path, conf, version, configFormat...)
+}
+
+func (a *ConsulConfigReader) update(ctx context.Context, path string, conf any, version common.Version, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	logger.Debug(ctx, "updating consul path : %s, version : %d", path, version)
 	data, err := common.Marshal(conf, configFormat...)
 	if err != nil {
 		logger.Error(ctx, "Error marshalling data : %v", err)
-		return err
+		return 0, err
 	}
+	return a.compareAndSwap(ctx, path, data, version)
+}
 
-	_, err = a.kv.Put(&api.KVPair{
-		Key:   path,
-		Value: data,
-	}, nil)
+// compareAndSwap writes data to the key if its ModifyIndex is version and records the previous value in the history.
+func (a *ConsulConfigReader) compareAndSwap(ctx context.Context, path string, data []byte, version common.Version) (common.Version, error) {
+	current, err := a.get(ctx, path, consistentQueryOptions(ctx))
+	if err != nil {
+		logger.Error(ctx, "Error reading from consul : %v", err)
+		return 0, err
+	}
+	if (current == nil && version != 0) || (current != nil && common.Version(current.ModifyIndex) != version) {
+		return 0, common.ErrVersionConflict
+	}
+
+	ok, err := a.cas(ctx, &api.KVPair{
+		Key:         path,
+		Value:       data,
+		ModifyIndex: uint64(version),
+	})
 	if err != nil {
 		logger.Error(ctx, "Error updating consul : %v", err)
+		return 0, err
+	}
+	if !ok {
+		return 0, common.ErrVersionConflict
+	}
+
+	if current != nil {
+		a.addHistory(ctx, path, current)
+	}
+	updated, err := a.get(ctx, path, consistentQueryOptions(ctx))
+	if err != nil {
+		logger.Error(ctx, "Error reading updated version from consul : %v", err)
+		return 0, err
+	}
+	if updated == nil {
+		return 0, nil
+	}
+	return common.Version(updated.ModifyIndex), nil
+}
+
+// addHistory stores the previous value of the key under path.history/ and removes the entries beyond the history size.
+// failures are logged and do not fail the update.
+func (a *ConsulConfigReader) addHistory(ctx context.Context, path string, previous *api.KVPair) {
+	if a.cfg.HistorySize < 0 {
+		return
+	}
+	prefix := path + historySuffix
+	err := a.put(ctx, &api.KVPair{
+		Key:   prefix + common.HistoryKey(common.Version(previous.ModifyIndex)),
+		Value: previous.Value,
+	})
+	if err != nil {
+		logger.Error(ctx, "Error writing history to consul : %v", err)
+		return
+	}
+	keys, err := a.keys(ctx, prefix)
+	if err != nil {
+		logger.Error(ctx, "Error listing history from consul : %v", err)
+		return
+	}
+	sort.Strings(keys)
+	for i := 0; i < len(keys)-a.cfg.HistorySize; i++ {
+		if err := a.delete(ctx, keys[i]); err != nil {
+			logger.Error(ctx, "Error deleting history from consul : %v", err)
+		}
+	}
+}
+
+// History returns the previous values of the configuration, newest first.
+// path : path in consul of the configuration
+func (a *ConsulConfigReader) History(ctx context.Context, path string) ([]common.HistoryEntry, error) {
+	prefix := a.cfg.Path + path + historySuffix
+	pairs, err := a.list(ctx, prefix)
+	if err != nil {
+		logger.Error(ctx, "Error listing history from consul : %v", err)
+		return nil, err
+	}
+	entries := make([]common.HistoryEntry, 0, len(pairs))
+	for _, pair := range pairs {
+		version, err := strconv.ParseUint(strings.TrimPrefix(pair.Key, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, common.HistoryEntry{Version: common.Version(version), Value: pair.Value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version > entries[j].Version })
+	return entries, nil
+}
+
+// Rollback restores the configuration to the value it had at the given version from the history.
+// The current value is kept in the history, so a rollback can be rolled back as well.
+// returns common.ErrVersionNotFound if the version is not in the history
+func (a *ConsulConfigReader) Rollback(ctx context.Context, path string, version common.Version) error {
+	entries, err := a.History(ctx, path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Version != version {
+			continue
+		}
+		path = a.cfg.Path + path
+		current, err := a.get(ctx, path, consistentQueryOptions(ctx))
+		if err != nil {
+			logger.Error(ctx, "Error reading from consul : %v", err)
+			return err
+		}
+		var currentVersion common.Version
+		if current != nil {
+			currentVersion = common.Version(current.ModifyIndex)
+		}
+		logger.Info(ctx, "rolling back consul path : %s, to version : %d", path, version)
+		_, err = a.compareAndSwap(ctx, path, entry.Value, currentVersion)
 		return err
 	}
-	return nil
+	return common.ErrVersionNotFound
+}
+
+// Watch calls onChange with the raw configuration data at path, initially and on every change, until ctx is done.
+// onChange is called with nil data when the key does not exist or is deleted.
+// It uses consul blocking queries on the ModifyIndex, errors are logged and the watch is retried after the retry backoff.
+func (a *ConsulConfigReader) Watch(ctx context.Context, path string, onChange func(data []byte)) error {
+	path = a.cfg.Path + path
+	var index uint64
+	// missing is set once onChange has been called for the missing key,
+	// the index of a missing key changes with the other keys of the kv store
+	missing := false
+	for {
+		opts := a.queryOptions(ctx)
+		opts.WaitIndex = index
+		opts.WaitTime = watchWaitTime
+		pair, meta, err := a.kv.Get(path, opts)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			logger.Error(ctx, "Error watching consul path : %s, err : %v", path, err)
+			select {
+			case <-time.After(a.cfg.RetryBackoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		// the index can go backwards when the raft log is reset, start over then
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		if meta.LastIndex == index {
+			continue
+		}
+		index = meta.LastIndex
+		if pair == nil {
+			if !missing {
+				missing = true
+				onChange(nil)
+			}
+			continue
+		}
+		missing = false
+		onChange(pair.Value)
+	}
 }
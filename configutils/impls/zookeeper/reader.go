@@ -2,48 +2,276 @@ package zookeeper
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-zookeeper/zk"
 	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/configutils/defaults"
 	"github.com/gofreego/goutils/logger"
 )
 
+const (
+	// historySuffix is appended to the config node to get the node holding the history
+	historySuffix = ".history"
+)
+
 // Config : configuration for zookeeper
-// Address : address of the zookeeper server
-// Path : path in zookeeper to read the configuration from
+// Address : address of the zookeeper server, use Servers for an ensemble
+// Servers : addresses of the servers of the zookeeper ensemble, Address is added to them if set
+// Path : base path in zookeeper, it is prefixed to the paths passed to Read and Update
 // Username : username for authentication
 // Password : password for authentication
+// SessionTimeout : zookeeper session timeout, also the time to wait for the session on connect, default 10s
+// ACL : acl of the nodes created by Update, one of world, auth, digest. default auth if username and password are provided else world
+// HistorySize : number of previous values kept for rollback, default 10, negative disables the history
+// SnapshotDir : directory to keep the last-known-good configurations in, Read falls back to them when zookeeper is unreachable, empty disables the snapshots
 type Config struct {
-	Address  string `yaml:"Address"`
-	Path     string `yaml:"Path"`
-	Username string `yaml:"Username"`
-	Password string `yaml:"Password"`
+	Address        string        `yaml:"Address"`
+	Servers        []string      `yaml:"Servers"`
+	Path           string        `yaml:"Path"`
+	Username       string        `yaml:"Username"`
+	Password       string        `yaml:"Password" secret:"true"`
+	SessionTimeout time.Duration `yaml:"SessionTimeout" default:"10s"`
+	ACL            string        `yaml:"ACL" choices:"world,auth,digest"`
+	HistorySize    int           `yaml:"HistorySize" default:"10"`
+	SnapshotDir    string        `yaml:"SnapshotDir"`
+}
+
+// WithDefaults sets the default tags on the zero valued fields, the default ACL depends on the authentication.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	if err := defaults.Apply(c); err != nil {
+		return err
+	}
+	if c.ACL == "" {
+		c.ACL = "world"
+		if c.hasAuth() {
+			c.ACL = "auth"
+		}
+	}
+	return nil
+}
+
+func (c *Config) hasAuth() bool {
+	return c.Username != "" && c.Password != ""
+}
+
+// servers returns the servers of the ensemble including Address.
+func (c *Config) servers() []string {
+	servers := append([]string{}, c.Servers...)
+	if c.Address != "" {
+		servers = append(servers, c.Address)
+	}
+	return servers
+}
+
+// acl returns the acl for the nodes created by the reader.
+func (c *Config) acl() []zk.ACL {
+	switch c.ACL {
+	case "auth":
+		return zk.AuthACL(zk.PermAll)
+	case "digest":
+		return zk.DigestACL(zk.PermAll, c.Username, c.Password)
+	default:
+		return zk.WorldACL(zk.PermAll)
+	}
+}
+
+// zkLogger routes the logs of the zookeeper client to the logger
+type zkLogger struct {
+	ctx context.Context
+}
+
+func (l zkLogger) Printf(format string, a ...any) {
+	logger.Debug(l.ctx, "zookeeper: "+format, a...)
+}
+
+// conn is the part of *zk.Conn used by the reader.
+type conn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+	Delete(path string, version int32) error
+	AddAuth(scheme string, auth []byte) error
+	State() zk.State
+	Close()
 }
 
 type ZookeeperReader struct {
-	conf *Config
-	conn *zk.Conn
+	conf      *Config
+	conn      conn
+	acl       []zk.ACL
+	snapshots *common.Snapshots
+}
+
+func newReader(config *Config, conn conn) *ZookeeperReader {
+	return &ZookeeperReader{conf: config, conn: conn, acl: config.acl(), snapshots: common.NewSnapshots(config.SnapshotDir, common.ZookeeperConfigReader)}
 }
 
 // NewZookeeperReader creates a new zookeeper configuration reader
+// It connects to the servers of the ensemble and waits until the session is established or the session timeout expires.
+// If SnapshotDir is set, the reader is returned even if no session is established, it keeps connecting and reads from the snapshots meanwhile.
 // if username and password are provided, it adds authentication to the connection else it connects without authentication
+// Close the reader to close the session.
 func NewZookeeperReader(ctx context.Context, config *Config) (*ZookeeperReader, error) {
-	conn, _, err := zk.Connect([]string{config.Address}, time.Second)
+	if err := config.WithDefaults(); err != nil {
+		logger.Error(ctx, "Error applying zookeeper config defaults : %v", err)
+		return nil, err
+	}
+	servers := config.servers()
+	if len(servers) == 0 {
+		logger.Error(ctx, "Error connecting to zookeeper : no servers configured")
+		return nil, common.ErrInvalidConfig
+	}
+	conn, events, err := zk.Connect(servers, config.SessionTimeout, zk.WithLogger(zkLogger{ctx: ctx}))
 	if err != nil {
 		logger.Error(ctx, "Error connecting to zookeeper : %v", err)
 		return nil, err
 	}
 
-	if config.Username != "" && config.Password != "" {
+	reader := newReader(config, conn)
+	err = waitForSession(ctx, events, config.SessionTimeout)
+	if errors.Is(err, common.ErrNotConnected) && config.SnapshotDir != "" {
+		logger.Warn(ctx, "zookeeper servers : %v unreachable, reading from the snapshots until the session is established", servers)
+		var onSession func()
+		if config.hasAuth() {
+			// the authentication is sent once the session is established, a pending request would be dropped on connect
+			onSession = newAuthenticator(ctx, conn, config.Username, config.Password).authenticate
+		}
+		go watchSession(ctx, events, onSession)
+		return reader, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Error connecting to zookeeper servers : %v, err : %v", servers, err)
+		conn.Close()
+		return nil, err
+	}
+	go watchSession(ctx, events, nil)
+
+	if config.hasAuth() {
 		err = conn.AddAuth("digest", []byte(config.Username+":"+config.Password))
 		if err != nil {
 			logger.Error(ctx, "Error adding authentication to zookeeper : %v", err)
+			conn.Close()
 			return nil, err
 		}
 	}
 
-	return &ZookeeperReader{conf: config, conn: conn}, nil
+	return reader, nil
+}
+
+// waitForSession waits for the session to be established.
+func waitForSession(ctx context.Context, events <-chan zk.Event, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return common.ErrNotConnected
+			}
+			switch event.State {
+			case zk.StateHasSession:
+				return nil
+			case zk.StateAuthFailed:
+				return zk.ErrAuthFailed
+			}
+		case <-timer.C:
+			return common.ErrNotConnected
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// watchSession logs the session state changes until the connection is closed, onSession is called on every established session if set.
+// the client reconnects and resends the authentication on its own, an expired session is replaced with a new one.
+func watchSession(ctx context.Context, events <-chan zk.Event, onSession func()) {
+	for event := range events {
+		if event.Type != zk.EventSession {
+			continue
+		}
+		switch event.State {
+		case zk.StateExpired:
+			logger.Warn(ctx, "zookeeper session expired, server : %s", event.Server)
+		case zk.StateDisconnected:
+			logger.Warn(ctx, "zookeeper disconnected, server : %s", event.Server)
+		case zk.StateHasSession:
+			logger.Info(ctx, "zookeeper session established, server : %s", event.Server)
+			if onSession != nil {
+				onSession()
+			}
+		}
+	}
+}
+
+// authenticator adds the digest authentication once a session is established, for the readers returned without a session.
+// the client only resends the authentications which succeeded, so a failed one is retried until it succeeds.
+type authenticator struct {
+	ctx     context.Context
+	conn    conn
+	auth    []byte
+	backoff time.Duration
+	running atomic.Bool
+	done    atomic.Bool
+}
+
+func newAuthenticator(ctx context.Context, conn conn, username, password string) *authenticator {
+	return &authenticator{ctx: ctx, conn: conn, auth: []byte(username + ":" + password), backoff: time.Second}
+}
+
+// authenticate adds the authentication in the background, it retries while the session lasts and is restarted by the next session.
+func (a *authenticator) authenticate() {
+	if a.done.Load() || !a.running.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		if a.retry() {
+			a.running.Store(false)
+			// a session established while stopping is not missed
+			if a.conn.State() == zk.StateHasSession {
+				a.authenticate()
+			}
+			return
+		}
+		a.running.Store(false)
+	}()
+}
+
+// retry adds the authentication until it succeeds, it returns true if it stops because the session is lost.
+func (a *authenticator) retry() bool {
+	backoff := a.backoff
+	for {
+		err := a.conn.AddAuth("digest", a.auth)
+		if err == nil {
+			a.done.Store(true)
+			logger.Info(a.ctx, "zookeeper authentication added")
+			return false
+		}
+		logger.Error(a.ctx, "Error adding authentication to zookeeper : %v", err)
+		if errors.Is(err, zk.ErrAuthFailed) || errors.Is(err, zk.ErrClosing) {
+			return false
+		}
+		time.Sleep(backoff)
+		if a.conn.State() != zk.StateHasSession {
+			return true
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// Close closes the zookeeper session.
+func (a *ZookeeperReader) Close() error {
+	a.conn.Close()
+	return nil
 }
 
 // Read reads the configuration from zookeeper
@@ -52,37 +280,271 @@ func NewZookeeperReader(ctx context.Context, config *Config) (*ZookeeperReader,
 // configFormat : format of the configuration data
 // returns error if any
 // returns nil if successful
+// If zookeeper is unreachable and SnapshotDir is set, the configuration is read from the last-known-good snapshot instead.
+// Invalid data read from zookeeper is returned as an error, it is not replaced by the snapshot.
 func (a *ZookeeperReader) Read(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
-	data, _, err := a.conn.Get(path)
+	_, err := a.ReadVersion(ctx, path, conf, configFormat...)
+	if err == nil || !isUnavailable(err) {
+		return err
+	}
+	data, snapshotErr := a.snapshots.Load(ctx, a.conf.Path+path, err)
+	if snapshotErr != nil {
+		return snapshotErr
+	}
+	return common.UnmarshalCopy(data, conf, configFormat...)
+}
+
+// isUnavailable reports whether zookeeper could not be reached, the reads fall back to the snapshots on these errors only.
+func isUnavailable(err error) bool {
+	for _, target := range []error{zk.ErrConnectionClosed, zk.ErrNoServer, zk.ErrSessionExpired, zk.ErrSessionMoved, zk.ErrClosing, context.DeadlineExceeded} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadVersion reads the configuration from zookeeper like Read and returns its version, the version of the node plus one.
+// conf is left unchanged if the data cannot be unmarshalled.
+// returns common.ErrConfigNotFound if the node does not exist
+func (a *ZookeeperReader) ReadVersion(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	path = a.conf.Path + path
+	logger.Debug(ctx, "reading from zookeeper path : %s", path)
+	data, stat, err := a.conn.Get(path)
+	if errors.Is(err, zk.ErrNoNode) {
+		logger.Error(ctx, "config not found in zookeeper path : %s", path)
+		return 0, common.ErrConfigNotFound
+	}
 	if err != nil {
 		logger.Error(ctx, "Error reading from zookeeper : %v", err)
-		return err
+		return 0, err
 	}
-	err = common.Unmarshal(data, conf, configFormat...)
+	err = common.UnmarshalCopy(data, conf, configFormat...)
 	if err != nil {
 		logger.Error(ctx, "Error unmarshalling for path: %s, data : %v", path, err)
-		return err
+		return 0, err
 	}
-	return nil
+	a.snapshots.Save(ctx, path, data)
+	return nodeVersion(stat), nil
 }
 
 // Update updates the configuration in zookeeper
+// The node and its parents are created if they do not exist.
+// The previous value is kept in the history, the update fails with common.ErrVersionConflict if the node is modified concurrently.
 // path : path in zookeeper to update the configuration
 // conf : configuration object to marshal the data from
 // configFormat : format of the configuration data
 // returns error if any
 // returns nil if successful
 func (a *ZookeeperReader) Update(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	var version common.Version
+	_, stat, err := a.conn.Get(a.conf.Path + path)
+	if err != nil && !errors.Is(err, zk.ErrNoNode) {
+		logger.Error(ctx, "Error reading from zookeeper : %v", err)
+		return err
+	}
+	if err == nil {
+		version = nodeVersion(stat)
+	}
+	_, err = a.UpdateVersion(ctx, path, conf, version, configFormat...)
+	return err
+}
+
+// UpdateVersion updates the configuration in zookeeper only if its current version is the given version.
+// version 0 creates the node and its parents, it fails with common.ErrVersionConflict if the node exists.
+// returns the new version, common.ErrVersionConflict if the version does not match
+func (a *ZookeeperReader) UpdateVersion(ctx context.Context, path string, conf any, version common.Version, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	path = a.conf.Path + path
+	logger.Debug(ctx, "updating zookeeper path : %s, version : %d", path, version)
 	bytes, err := common.Marshal(conf, configFormat...)
 	if err != nil {
 		logger.Error(ctx, "Error marshalling for path: %s, err: %v", path, err)
-		return err
+		return 0, err
+	}
+	return a.compareAndSwap(ctx, path, bytes, version)
+}
+
+// nodeVersion returns the version of the node plus one, so that version 0 always means the node does not exist,
+// a node which has just been created has the zookeeper version 0.
+func nodeVersion(stat *zk.Stat) common.Version {
+	return common.Version(stat.Version) + 1
+}
+
+// compareAndSwap writes data to the node if its version is version and records the previous value in the history.
+// version 0 only creates the node, so that one of two concurrent creators fails.
+func (a *ZookeeperReader) compareAndSwap(ctx context.Context, path string, data []byte, version common.Version) (common.Version, error) {
+	if version == 0 {
+		if err := a.create(ctx, path, data); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	previous, stat, err := a.conn.Get(path)
+	if errors.Is(err, zk.ErrNoNode) {
+		return 0, common.ErrVersionConflict
+	}
+	if err != nil {
+		logger.Error(ctx, "Error reading from zookeeper : %v", err)
+		return 0, err
+	}
+	if nodeVersion(stat) != version {
+		return 0, common.ErrVersionConflict
 	}
 
-	_, err = a.conn.Set(path, bytes, -1)
+	stat, err = a.conn.Set(path, data, int32(version-1))
+	if errors.Is(err, zk.ErrBadVersion) {
+		return 0, common.ErrVersionConflict
+	}
 	if err != nil {
 		logger.Error(ctx, "Error writing to zookeeper : %v", err)
+		return 0, err
+	}
+	a.addHistory(ctx, path, version, previous)
+	return nodeVersion(stat), nil
+}
+
+// create creates the node with data, missing parent nodes are created empty.
+// returns common.ErrVersionConflict if the node is created by someone else in between.
+func (a *ZookeeperReader) create(ctx context.Context, path string, data []byte) error {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		_, err := a.conn.Create("/"+strings.Join(parts[:i], "/"), nil, 0, a.acl)
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			logger.Error(ctx, "Error creating parent node in zookeeper : %v", err)
+			return err
+		}
+	}
+	logger.Info(ctx, "creating zookeeper node : %s", path)
+	_, err := a.conn.Create(path, data, 0, a.acl)
+	if errors.Is(err, zk.ErrNodeExists) {
+		return common.ErrVersionConflict
+	}
+	if err != nil {
+		logger.Error(ctx, "Error creating node in zookeeper : %v", err)
 		return err
 	}
 	return nil
 }
+
+// addHistory stores the previous value of the node as a child of path.history and removes the entries beyond the history size.
+// failures are logged and do not fail the update.
+func (a *ZookeeperReader) addHistory(ctx context.Context, path string, version common.Version, previous []byte) {
+	if a.conf.HistorySize < 0 {
+		return
+	}
+	historyPath := path + historySuffix
+	_, err := a.conn.Create(historyPath, nil, 0, a.acl)
+	if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+		logger.Error(ctx, "Error creating history node in zookeeper : %v", err)
+		return
+	}
+	_, err = a.conn.Create(historyPath+"/"+common.HistoryKey(version), previous, 0, a.acl)
+	if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+		logger.Error(ctx, "Error writing history to zookeeper : %v", err)
+		return
+	}
+	children, _, err := a.conn.Children(historyPath)
+	if err != nil {
+		logger.Error(ctx, "Error listing history from zookeeper : %v", err)
+		return
+	}
+	sort.Strings(children)
+	for i := 0; i < len(children)-a.conf.HistorySize; i++ {
+		if err := a.conn.Delete(historyPath+"/"+children[i], -1); err != nil {
+			logger.Error(ctx, "Error deleting history from zookeeper : %v", err)
+		}
+	}
+}
+
+// History returns the previous values of the configuration, newest first.
+// path : path in zookeeper of the configuration
+func (a *ZookeeperReader) History(ctx context.Context, path string) ([]common.HistoryEntry, error) {
+	historyPath := a.conf.Path + path + historySuffix
+	children, _, err := a.conn.Children(historyPath)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Error listing history from zookeeper : %v", err)
+		return nil, err
+	}
+	entries := make([]common.HistoryEntry, 0, len(children))
+	for _, child := range children {
+		version, err := strconv.ParseUint(child, 10, 64)
+		if err != nil {
+			continue
+		}
+		data, _, err := a.conn.Get(historyPath + "/" + child)
+		if err != nil {
+			logger.Error(ctx, "Error reading history from zookeeper : %v", err)
+			return nil, err
+		}
+		entries = append(entries, common.HistoryEntry{Version: common.Version(version), Value: data})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version > entries[j].Version })
+	return entries, nil
+}
+
+// Rollback restores the configuration to the value it had at the given version from the history.
+// The current value is kept in the history, so a rollback can be rolled back as well.
+// returns common.ErrVersionNotFound if the version is not in the history
+func (a *ZookeeperReader) Rollback(ctx context.Context, path string, version common.Version) error {
+	path = a.conf.Path + path
+	data, _, err := a.conn.Get(path + historySuffix + "/" + common.HistoryKey(version))
+	if errors.Is(err, zk.ErrNoNode) {
+		return common.ErrVersionNotFound
+	}
+	if err != nil {
+		logger.Error(ctx, "Error reading history from zookeeper : %v", err)
+		return err
+	}
+	_, stat, err := a.conn.Get(path)
+	if err != nil {
+		logger.Error(ctx, "Error reading from zookeeper : %v", err)
+		return err
+	}
+	logger.Info(ctx, "rolling back zookeeper path : %s, to version : %d", path, version)
+	_, err = a.compareAndSwap(ctx, path, data, nodeVersion(stat))
+	return err
+}
+
+// Watch calls onChange with the raw configuration data at path, initially and on every change, until ctx is done.
+// onChange is called with nil data when the node does not exist or is deleted.
+// It sets a zookeeper data watch on the node, errors are logged and the watch is retried after a second.
+func (a *ZookeeperReader) Watch(ctx context.Context, path string, onChange func(data []byte)) error {
+	path = a.conf.Path + path
+	for {
+		data, _, events, err := a.conn.GetW(path)
+		if err != nil {
+			if !errors.Is(err, zk.ErrNoNode) {
+				logger.Error(ctx, "Error watching zookeeper path : %s, err : %v", path, err)
+			}
+			// a missing node is watched with ExistsW, so that its creation is noticed
+			if errors.Is(err, zk.ErrNoNode) {
+				var exists bool
+				exists, _, events, err = a.conn.ExistsW(path)
+				if err == nil && exists {
+					continue
+				}
+				if err == nil {
+					onChange(nil)
+				}
+			}
+			if err != nil {
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+		} else {
+			onChange(data)
+		}
+		select {
+		case <-events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
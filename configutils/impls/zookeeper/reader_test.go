@@ -0,0 +1,511 @@
+package zookeeper
+
+import (
+	"context"
+	"errors"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/gofreego/goutils/configutils/common"
+)
+
+// fakeNode is a node of the fake zookeeper server.
+type fakeNode struct {
+	data    []byte
+	version int32
+	acl     []zk.ACL
+}
+
+// fakeZK is an embedded in-memory zookeeper server, it enforces the read and write permissions of the digest acls.
+type fakeZK struct {
+	mu     sync.Mutex
+	nodes  map[string]*fakeNode
+	auths  []string
+	state  zk.State
+	closed bool
+	// authErrs are returned by the next AddAuth calls
+	authErrs []error
+	// watches are the one-shot watches set by GetW and ExistsW, by path
+	watches map[string][]chan zk.Event
+}
+
+func newFakeZK() *fakeZK {
+	return &fakeZK{nodes: map[string]*fakeNode{"/": {acl: zk.WorldACL(zk.PermAll)}}, state: zk.StateHasSession}
+}
+
+// allowed reports whether the session has the permission on the node.
+func (f *fakeZK) allowed(node *fakeNode, perm int32) bool {
+	for _, acl := range node.acl {
+		if acl.Perms&perm == 0 {
+			continue
+		}
+		if acl.Scheme == "world" && acl.ID == "anyone" {
+			return true
+		}
+		for _, auth := range f.auths {
+			if acl.Scheme == "digest" && acl.ID == digestID(auth) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func digestID(auth string) string {
+	user, password, _ := strings.Cut(auth, ":")
+	return zk.DigestACL(zk.PermAll, user, password)[0].ID
+}
+
+func (f *fakeZK) node(p string, perm int32) (*fakeNode, error) {
+	if f.closed {
+		return nil, zk.ErrClosing
+	}
+	node, ok := f.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	if !f.allowed(node, perm) {
+		return nil, zk.ErrNoAuth
+	}
+	return node, nil
+}
+
+func (f *fakeZK) Get(p string) ([]byte, *zk.Stat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	node, err := f.node(p, zk.PermRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	return node.data, &zk.Stat{Version: node.version}, nil
+}
+
+// watch sets a one-shot watch on the node.
+func (f *fakeZK) watch(p string) <-chan zk.Event {
+	events := make(chan zk.Event, 1)
+	if f.watches == nil {
+		f.watches = map[string][]chan zk.Event{}
+	}
+	f.watches[p] = append(f.watches[p], events)
+	return events
+}
+
+// trigger fires the watches of the node.
+func (f *fakeZK) trigger(p string, eventType zk.EventType) {
+	for _, events := range f.watches[p] {
+		events <- zk.Event{Type: eventType, Path: p}
+	}
+	delete(f.watches, p)
+}
+
+func (f *fakeZK) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	node, err := f.node(p, zk.PermRead)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return node.data, &zk.Stat{Version: node.version}, f.watch(p), nil
+}
+
+func (f *fakeZK) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	node, ok := f.nodes[p]
+	if !ok {
+		return false, nil, f.watch(p), nil
+	}
+	return true, &zk.Stat{Version: node.version}, f.watch(p), nil
+}
+
+func (f *fakeZK) Children(p string) ([]string, *zk.Stat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.node(p, zk.PermRead); err != nil {
+		return nil, nil, err
+	}
+	var children []string
+	for child := range f.nodes {
+		if child != "/" && path.Dir(child) == p {
+			children = append(children, path.Base(child))
+		}
+	}
+	sort.Strings(children)
+	return children, &zk.Stat{}, nil
+}
+
+func (f *fakeZK) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.node(path.Dir(p), zk.PermCreate); err != nil {
+		return "", err
+	}
+	if _, ok := f.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	// the auth scheme is the digest of the authenticated users of the session, as in zookeeper
+	var nodeACL []zk.ACL
+	for _, a := range acl {
+		if a.Scheme != "auth" {
+			nodeACL = append(nodeACL, a)
+			continue
+		}
+		if len(f.auths) == 0 {
+			return "", zk.ErrInvalidACL
+		}
+		for _, auth := range f.auths {
+			nodeACL = append(nodeACL, zk.ACL{Perms: a.Perms, Scheme: "digest", ID: digestID(auth)})
+		}
+	}
+	f.nodes[p] = &fakeNode{data: data, acl: nodeACL}
+	f.trigger(p, zk.EventNodeCreated)
+	return p, nil
+}
+
+func (f *fakeZK) Set(p string, data []byte, version int32) (*zk.Stat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	node, err := f.node(p, zk.PermWrite)
+	if err != nil {
+		return nil, err
+	}
+	if version != -1 && version != node.version {
+		return nil, zk.ErrBadVersion
+	}
+	node.data = data
+	node.version++
+	f.trigger(p, zk.EventNodeDataChanged)
+	return &zk.Stat{Version: node.version}, nil
+}
+
+func (f *fakeZK) Delete(p string, version int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.node(path.Dir(p), zk.PermDelete); err != nil {
+		return err
+	}
+	if _, ok := f.nodes[p]; !ok {
+		return zk.ErrNoNode
+	}
+	delete(f.nodes, p)
+	f.trigger(p, zk.EventNodeDeleted)
+	return nil
+}
+
+func (f *fakeZK) AddAuth(scheme string, auth []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return zk.ErrClosing
+	}
+	if len(f.authErrs) > 0 {
+		err := f.authErrs[0]
+		f.authErrs = f.authErrs[1:]
+		return err
+	}
+	f.auths = append(f.auths, string(auth))
+	return nil
+}
+
+func (f *fakeZK) State() zk.State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state
+}
+
+func (f *fakeZK) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+type testConfig struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+func newTestReader(t *testing.T, config *Config, fake *fakeZK) *ZookeeperReader {
+	t.Helper()
+	config.WithDefaults()
+	if config.hasAuth() {
+		if err := fake.AddAuth("digest", []byte(config.Username+":"+config.Password)); err != nil {
+			t.Fatalf("AddAuth() = %v", err)
+		}
+	}
+	return newReader(config, fake)
+}
+
+func TestBasePath(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{Path: "/services/orders"}, fake)
+
+	if err := reader.Update(ctx, "/config", &testConfig{Name: "v1"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if _, ok := fake.nodes["/services/orders/config"]; !ok {
+		t.Fatal("node /services/orders/config not created")
+	}
+	if _, ok := fake.nodes["/config"]; ok {
+		t.Fatal("node /config created without the base path")
+	}
+	var conf testConfig
+	if err := reader.Read(ctx, "/config", &conf, common.ConfigFormatJSON); err != nil || conf.Name != "v1" {
+		t.Fatalf("Read() = %+v, %v, want v1", conf, err)
+	}
+	if err := reader.Read(ctx, "/missing", &conf, common.ConfigFormatJSON); !errors.Is(err, common.ErrConfigNotFound) {
+		t.Fatalf("Read() of a missing node = %v, want ErrConfigNotFound", err)
+	}
+}
+
+func TestReadFallback(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{SnapshotDir: t.TempDir()}, fake)
+	if err := reader.Update(ctx, "/config", &testConfig{Name: "v1", Port: 80}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	var conf testConfig
+	if err := reader.Read(ctx, "/config", &conf, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	// invalid data is an error, the snapshot does not replace it and conf is not partially set
+	fake.nodes["/config"].data = []byte(`{"name":"v2","port":"eighty"}`)
+	conf = testConfig{}
+	if err := reader.Read(ctx, "/config", &conf, common.ConfigFormatJSON); err == nil || conf != (testConfig{}) {
+		t.Fatalf("Read() of invalid data = %+v, %v, want an error and conf unchanged", conf, err)
+	}
+
+	// the snapshot is served while zookeeper is unreachable
+	fake.Close()
+	if err := reader.Read(ctx, "/config", &conf, common.ConfigFormatJSON); err != nil || conf.Name != "v1" || conf.Port != 80 {
+		t.Fatalf("Read() while closed = %+v, %v, want the v1 snapshot", conf, err)
+	}
+}
+
+func TestUpdateCreatesNodes(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{}, fake)
+
+	if err := reader.Update(ctx, "/a/b/c", &testConfig{Name: "v1"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	for _, parent := range []string{"/a", "/a/b"} {
+		node, ok := fake.nodes[parent]
+		if !ok {
+			t.Fatalf("parent node %s not created", parent)
+		}
+		if len(node.data) != 0 {
+			t.Fatalf("parent node %s data = %q, want empty", parent, node.data)
+		}
+	}
+	if err := reader.Update(ctx, "/a/b/c", &testConfig{Name: "v2"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("second Update() = %v", err)
+	}
+	if node := fake.nodes["/a/b/c"]; node.version != 1 || string(node.data) == "" {
+		t.Fatalf("node /a/b/c version = %d, want 1", node.version)
+	}
+	history, err := reader.History(ctx, "/a/b/c")
+	if err != nil || len(history) != 1 || history[0].Version != 1 {
+		t.Fatalf("History() = %+v, %v, want version 1", history, err)
+	}
+	if _, err := reader.UpdateVersion(ctx, "/a/b/c", &testConfig{Name: "v3"}, 0, common.ConfigFormatJSON); !errors.Is(err, common.ErrVersionConflict) {
+		t.Fatalf("UpdateVersion() with a stale version = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{}, fake)
+
+	names := []string{"first", "second"}
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = reader.UpdateVersion(ctx, "/config", &testConfig{Name: name}, 0, common.ConfigFormatJSON)
+		}()
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && winner == -1:
+			winner = i
+		case !errors.Is(err, common.ErrVersionConflict):
+			t.Fatalf("UpdateVersion() errors = %v, want one nil and one ErrVersionConflict", errs)
+		}
+	}
+	if winner == -1 {
+		t.Fatalf("UpdateVersion() errors = %v, want one creator to succeed", errs)
+	}
+	var conf testConfig
+	version, err := reader.ReadVersion(ctx, "/config", &conf, common.ConfigFormatJSON)
+	if err != nil || conf.Name != names[winner] || version != 1 {
+		t.Fatalf("ReadVersion() = %d, %+v, %v, want version 1 of %s", version, conf, err, names[winner])
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{}, fake)
+
+	changes := make(chan []byte, 10)
+	done := make(chan error)
+	go func() { done <- reader.Watch(ctx, "/config", func(data []byte) { changes <- data }) }()
+	next := func() []byte {
+		t.Helper()
+		select {
+		case data := <-changes:
+			return data
+		case <-time.After(2 * time.Second):
+			t.Fatal("Watch() did not call onChange")
+			return nil
+		}
+	}
+
+	// a missing node, then its creation, update and deletion
+	if data := next(); data != nil {
+		t.Fatalf("onChange(%q) of a missing node, want nil", data)
+	}
+	if err := reader.Update(ctx, "/config", &testConfig{Name: "v1"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if data := next(); !strings.Contains(string(data), "v1") {
+		t.Fatalf("onChange(%q), want v1", data)
+	}
+	if err := reader.Update(ctx, "/config", &testConfig{Name: "v2"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if data := next(); !strings.Contains(string(data), "v2") {
+		t.Fatalf("onChange(%q), want v2", data)
+	}
+	if err := fake.Delete(reader.conf.Path+"/config", -1); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	if data := next(); data != nil {
+		t.Fatalf("onChange(%q) of a deleted node, want nil", data)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Watch() = %v, want context.Canceled", err)
+	}
+}
+
+func TestACL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []zk.ACL
+	}{
+		{name: "default without auth", config: Config{}, want: zk.WorldACL(zk.PermAll)},
+		{name: "default with auth", config: Config{Username: "app", Password: "secret"}, want: zk.AuthACL(zk.PermAll)},
+		{name: "world", config: Config{Username: "app", Password: "secret", ACL: "world"}, want: zk.WorldACL(zk.PermAll)},
+		{name: "digest", config: Config{Username: "app", Password: "secret", ACL: "digest"}, want: zk.DigestACL(zk.PermAll, "app", "secret")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.WithDefaults()
+			if got := test.config.acl(); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("acl() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{Username: "app", Password: "secret"}, fake)
+	if err := reader.Update(ctx, "/secure/config", &testConfig{Name: "v1"}, common.ConfigFormatJSON); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	for _, p := range []string{"/secure", "/secure/config"} {
+		if got, want := fake.nodes[p].acl, zk.DigestACL(zk.PermAll, "app", "secret"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("node %s acl = %+v, want %+v", p, got, want)
+		}
+	}
+
+	// another session without the authentication cannot read the node
+	other := newFakeZK()
+	other.nodes = fake.nodes
+	var conf testConfig
+	if _, err := newTestReader(t, &Config{}, other).ReadVersion(ctx, "/secure/config", &conf, common.ConfigFormatJSON); !errors.Is(err, zk.ErrNoAuth) {
+		t.Fatalf("ReadVersion() without auth = %v, want ErrNoAuth", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeZK()
+	reader := newTestReader(t, &Config{}, fake)
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if !fake.closed {
+		t.Fatal("Close() did not close the connection")
+	}
+	var conf testConfig
+	if err := reader.Read(ctx, "/config", &conf, common.ConfigFormatJSON); !errors.Is(err, zk.ErrClosing) {
+		t.Fatalf("Read() after Close() = %v, want ErrClosing", err)
+	}
+}
+
+func TestAuthenticatorRetries(t *testing.T) {
+	fake := newFakeZK()
+	fake.authErrs = []error{zk.ErrNoServer, zk.ErrConnectionClosed}
+	a := newAuthenticator(context.Background(), fake, "app", "secret")
+	a.backoff = time.Millisecond
+
+	a.authenticate()
+	deadline := time.Now().Add(time.Second)
+	for !a.done.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !a.done.Load() {
+		t.Fatal("authentication not added after the failures")
+	}
+	if want := []string{"app:secret"}; !reflect.DeepEqual(fake.auths, want) {
+		t.Fatalf("auths = %q, want %q", fake.auths, want)
+	}
+}
+
+func TestAuthenticatorWaitsForSession(t *testing.T) {
+	fake := newFakeZK()
+	fake.authErrs = []error{zk.ErrNoServer}
+	fake.state = zk.StateDisconnected
+	a := newAuthenticator(context.Background(), fake, "app", "secret")
+	a.backoff = time.Millisecond
+
+	// the session is lost after the failure, the next session restarts the authentication
+	a.authenticate()
+	deadline := time.Now().Add(time.Second)
+	for a.running.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if a.done.Load() {
+		t.Fatal("authentication added without a session")
+	}
+	fake.mu.Lock()
+	fake.state = zk.StateHasSession
+	fake.mu.Unlock()
+	a.authenticate()
+	for !a.done.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !a.done.Load() {
+		t.Fatal("authentication not added on the next session")
+	}
+}
@@ -0,0 +1,196 @@
+// Package fields walks the fields of configuration structs, it is shared by the config loaders and the format codecs.
+package fields
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationType is the reflect type of time.Duration, durations are parsed with time.ParseDuration.
+var DurationType = reflect.TypeOf(time.Duration(0))
+
+// Visitor is called for every leaf field of a config struct.
+// path : yaml key path of the field, e.g. [Repository Postgres Primary Host]
+// field : struct field definition, gives access to the tags
+// value : settable value of the field
+type Visitor func(path []string, field reflect.StructField, value reflect.Value) error
+
+// Walk walks all exported leaf fields of the struct pointed by conf.
+// Nested structs, non nil pointers to structs and elements of slices of structs are walked recursively, element index is added to the path.
+// Maps are treated as leaves.
+func Walk(conf any, visit Visitor) error {
+	v := reflect.ValueOf(conf)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("config must be a non nil pointer to struct, got %T", conf)
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a non nil pointer to struct, got %T", conf)
+	}
+	return walkStruct(v, nil, visit)
+}
+
+// IsStructPointer reports whether conf can be walked with Walk.
+func IsStructPointer(conf any) bool {
+	v := reflect.ValueOf(conf)
+	return v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct
+}
+
+func walkStruct(v reflect.Value, prefix []string, visit Visitor) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := Name(field)
+		if name == "-" {
+			continue
+		}
+		path := append(append([]string{}, prefix...), name)
+		value := v.Field(i)
+
+		switch {
+		case value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}):
+			if err := walkStruct(value, path, visit); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Pointer && value.Type().Elem().Kind() == reflect.Struct:
+			if value.IsNil() {
+				continue
+			}
+			if err := walkStruct(value.Elem(), path, visit); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < value.Len(); j++ {
+				if err := walkStruct(value.Index(j), append(path, strconv.Itoa(j)), visit); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := visit(path, field, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Name returns the yaml key of the field.
+// Like yaml, it falls back to the lower cased go field name if the field has no yaml tag.
+func Name(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// SetFromString parses s according to the kind of value and sets it.
+// It supports strings, bools, numbers, time.Duration and slices of these as comma separated values.
+func SetFromString(value reflect.Value, s string) error {
+	if value.Type() == DurationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(value.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := SetFromString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := SetFromString(elem.Elem(), s); err != nil {
+			return err
+		}
+		value.Set(elem)
+	default:
+		return fmt.Errorf("unsupported field type %s", value.Type())
+	}
+	return nil
+}
+
+// IsScalar reports whether a value of type t can be set with SetFromString.
+func IsScalar(t reflect.Type) bool {
+	if t == DurationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice, reflect.Pointer:
+		return IsScalar(t.Elem())
+	}
+	return false
+}
+
+// String returns the printable representation of the value, nil pointers are printed as empty string.
+func String(value reflect.Value) string {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
+
+// ApplyDefaults sets the value of the default tag on every zero valued field of conf, onSet is called with the path of every field set, if not nil.
+func ApplyDefaults(conf any, onSet func(path []string)) error {
+	return Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		def, ok := field.Tag.Lookup("default")
+		if !ok || !value.IsZero() {
+			return nil
+		}
+		if err := SetFromString(value, def); err != nil {
+			return fmt.Errorf("invalid default value for %s, Err: %w", strings.Join(path, "."), err)
+		}
+		if onSet != nil {
+			onSet(path)
+		}
+		return nil
+	})
+}
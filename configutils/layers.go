@@ -0,0 +1,179 @@
+package configutils
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofreego/goutils/configutils/internal/fields"
+)
+
+// Source represents where a configuration value was loaded from.
+type Source string
+
+const (
+	SourceFile   Source = "file"
+	SourceRemote Source = "remote"
+	SourceEnv    Source = "env"
+	SourceFlag   Source = "flag"
+	// SourceDefault marks values set from the default tag of the field
+	SourceDefault Source = "default"
+)
+
+// Options represents the options for layered configuration loading.
+// Layers are applied in the order file, remote reader, env, flags, every layer overrides the previous one.
+// Defaults from the default tags are applied to the fields left zero by all layers.
+// EnvPrefix enables env overrides, e.g. with prefix APP the field Repository.Postgres.Primary.Host is read from APP_REPOSITORY_POSTGRES_PRIMARY_HOST
+// Args enables flag overrides, e.g. -repository.postgres.primary.host=localhost, usually os.Args[1:], the other flags of the app are ignored
+// Names of env variables and flags are derived from the yaml tags of the config struct.
+// Path is the path of the config file, it is used by Load.
+type Options struct {
+	Path      string
+	EnvPrefix string
+	Args      []string
+}
+
+// Report records the source of every configuration value, keyed by the dotted yaml path of the field.
+// Fields which are not set by any layer are not present in the report.
+type Report map[string]Source
+
+// String returns the report as sorted "path: source" lines.
+func (r Report) String() string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "%s: %s\n", key, r[key])
+	}
+	return sb.String()
+}
+
+// snapshot returns the printable value of every non zero leaf field of conf.
+// It returns nil if conf is not a pointer to struct.
+func snapshot(conf any) map[string]string {
+	values := map[string]string{}
+	err := fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		if !value.IsZero() {
+			values[strings.Join(path, ".")] = fields.String(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	return values
+}
+
+// record marks every field of conf which changed since before as loaded from source.
+// It returns the new snapshot.
+func (r Report) record(conf any, before map[string]string, source Source) map[string]string {
+	after := snapshot(conf)
+	for key, value := range after {
+		if before[key] != value {
+			r[key] = source
+		}
+	}
+	return after
+}
+
+// applyEnv overrides the fields of conf with the values of the matching env variables.
+func applyEnv(conf any, prefix string, report Report) error {
+	return fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		if !fields.IsScalar(value.Type()) {
+			return nil
+		}
+		name := prefix + "_" + strings.ToUpper(strings.Join(path, "_"))
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := fields.SetFromString(value, env); err != nil {
+			return fmt.Errorf("invalid value for env %s, Err: %w", name, err)
+		}
+		report[strings.Join(path, ".")] = SourceEnv
+		return nil
+	})
+}
+
+// fieldFlag is a flag.Value which sets a config field.
+type fieldFlag struct {
+	value reflect.Value
+}
+
+func (f *fieldFlag) String() string {
+	if !f.value.IsValid() {
+		return ""
+	}
+	return fields.String(f.value)
+}
+
+func (f *fieldFlag) Set(s string) error {
+	return fields.SetFromString(f.value, s)
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.value.IsValid() && f.value.Kind() == reflect.Bool
+}
+
+// applyFlags overrides the fields of conf with the values of the matching flags in args.
+// The flags which do not match a field, e.g. the flags of the app, and the positional arguments are ignored.
+func applyFlags(conf any, args []string, report Report) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	keys := map[string]string{}
+	err := fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		if !fields.IsScalar(value.Type()) {
+			return nil
+		}
+		name := strings.ToLower(strings.Join(path, "."))
+		keys[name] = strings.Join(path, ".")
+		fs.Var(&fieldFlag{value: value}, name, field.Tag.Get("description"))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(configArgs(fs, args)); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		report[keys[f.Name]] = SourceFlag
+	})
+	return nil
+}
+
+// configArgs returns the flags of args defined in fs with their values, the other arguments are dropped.
+// Parsing stops at the -- terminator.
+func configArgs(fs *flag.FlagSet, args []string) []string {
+	var filtered []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		filtered = append(filtered, arg)
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); hasValue || (ok && boolFlag.IsBoolFlag()) {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			filtered = append(filtered, args[i])
+		}
+	}
+	return filtered
+}
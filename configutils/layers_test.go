@@ -0,0 +1,38 @@
+package configutils
+
+import (
+	"testing"
+	"time"
+)
+
+type flagsConfig struct {
+	Server struct {
+		Host    string        `yaml:"Host"`
+		Port    int           `yaml:"Port"`
+		Timeout time.Duration `yaml:"Timeout"`
+		Debug   bool          `yaml:"Debug"`
+	} `yaml:"Server"`
+}
+
+func TestApplyFlags(t *testing.T) {
+	var conf flagsConfig
+	report := Report{}
+	args := []string{
+		"-v", "--workers", "4", "serve",
+		"-server.host", "localhost", "--server.port=8080", "-server.debug",
+		"-app-flag=x", "--", "-server.timeout=1s",
+	}
+	if err := applyFlags(&conf, args, report); err != nil {
+		t.Fatalf("applyFlags() = %v", err)
+	}
+	if conf.Server.Host != "localhost" || conf.Server.Port != 8080 || !conf.Server.Debug || conf.Server.Timeout != 0 {
+		t.Fatalf("conf = %+v", conf.Server)
+	}
+	if report["Server.Host"] != SourceFlag || report["Server.Port"] != SourceFlag || len(report) != 3 {
+		t.Fatalf("report = %v", report)
+	}
+
+	if err := applyFlags(&conf, []string{"-server.port=abc"}, Report{}); err == nil {
+		t.Fatal("applyFlags() with an invalid value = nil, want error")
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"github.com/gofreego/goutils/configutils/impls/consul"
 	"github.com/gofreego/goutils/configutils/impls/file"
 	"github.com/gofreego/goutils/configutils/impls/zookeeper"
+	"github.com/gofreego/goutils/configutils/internal/fields"
 	"github.com/gofreego/goutils/logger"
 )
 
 // Config represents the configuration for the config reader.
 // Name is the type of the config reader, Expect one of consul, zookeeper, database, file
-// Format is the format of the configuration data, Expect one of json, yaml
+// Format is the format of the configuration data, Expect one of json, yaml, toml, hcl, dotenv
 // Consul is the configuration for consul reader
 // Zookeeper is the configuration for zookeeper reader
 // Database is the configuration for database reader
@@ -35,6 +36,30 @@ type ConfigReader interface {
 	Update(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error
 }
 
+// VersionedConfigReader is implemented by the config readers which support versioned updates, consul and zookeeper.
+// Use it for read-modify-write cycles, so that concurrent editors do not overwrite each other.
+// e.g. reader.(configutils.VersionedConfigReader)
+type VersionedConfigReader interface {
+	ConfigReader
+	// ReadVersion reads the configuration like Read and returns its current version.
+	ReadVersion(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) (common.Version, error)
+	// UpdateVersion updates the configuration only if its current version is the given version.
+	// returns the new version, common.ErrVersionConflict if the configuration is modified in between
+	UpdateVersion(ctx context.Context, path string, conf any, version common.Version, configFormat ...common.ConfigFormatType) (common.Version, error)
+	// History returns the bounded history of previous values of the configuration, newest first.
+	History(ctx context.Context, path string) ([]common.HistoryEntry, error)
+	// Rollback restores the configuration to the value it had at the given version.
+	Rollback(ctx context.Context, path string, version common.Version) error
+}
+
+// Watcher is implemented by the config readers which can notify about configuration changes, consul and zookeeper.
+type Watcher interface {
+	// Watch calls onChange with the raw configuration data at path, initially and on every change, until ctx is done.
+	// onChange is called with nil data when the path does not exist or is deleted.
+	// It blocks and returns ctx.Err() when ctx is done.
+	Watch(ctx context.Context, path string, onChange func(data []byte)) error
+}
+
 // NewConfigReader creates a new config reader based on the given configuration.
 // it is recommended to use config reader for reading configuration from consul, zookeeper, database on production and not use file.
 func NewConfigReader(ctx context.Context, conf *Config) (ConfigReader, error) {
@@ -58,55 +83,116 @@ type tConfig interface {
 // ReadConfig reads the configuration from the given path and unmarshals it into the given conf.
 // It reads the configuration from the file
 // If conf implements tConfig, it reads the configuration from the reader specified in the configuration.
-// It supports json and yaml formats.
-// If no format is provided, it defaults to yaml.
+// The format of the file is detected from its extension, it supports json, yaml, toml, hcl and dotenv formats and defaults to yaml.
+// It resolves the secret references like ${env:DB_PASS} and ${file:/run/secrets/db_pass}.
+// It applies the default tags and validates the configuration, it fails with a *ValidationError listing every violation.
 // It returns error if any.
 // It returns nil if successful.
 // it is recommended to use config reader for reading configuration from consul, zookeeper, database on production and not use file.
 func ReadConfig(ctx context.Context, path string, conf any) error {
+	_, err := ReadConfigWithOptions(ctx, path, conf, nil)
+	return err
+}
+
+// ReadConfigWithOptions reads the configuration like ReadConfig and then applies the env and flag overrides enabled in opts.
+// The secret references of the reader config are resolved before the remote reader is created.
+// Afterwards the secret references are resolved, the default tags are applied and the configuration is validated, see ResolveSecrets, ApplyDefaults and Validate.
+// It returns a report of the source of every configuration value.
+// opts can be nil, in which case only the file and the remote reader are used.
+func ReadConfigWithOptions(ctx context.Context, path string, conf any, opts *Options) (Report, error) {
 	if conf == nil {
-		return common.ErrInvalidConfig
+		return nil, common.ErrInvalidConfig
 	}
+	report := Report{}
+	values := snapshot(conf)
 
-	err := file.NewFileConfigReader(&file.Config{Path: path}).Read(ctx, "", conf, common.ConfigFormatYAML)
+	err := file.NewFileConfigReader(&file.Config{Path: path}).Read(ctx, "", conf, common.FormatFromPath(path))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if conf, ok := conf.(tConfig); ok {
-		if conf.GetReaderConfig() == nil {
-			return nil
+	values = report.record(conf, values, SourceFile)
+
+	if conf, ok := conf.(tConfig); ok && conf.GetReaderConfig() != nil {
+		// the secrets of the reader config, e.g. the consul token, are resolved before connecting
+		if err := ResolveSecrets(ctx, conf.GetReaderConfig()); err != nil {
+			logger.Error(ctx, "error resolving config reader secrets : %v", err)
+			return nil, err
 		}
 		reader, err := NewConfigReader(ctx, conf.GetReaderConfig())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		frmt := conf.GetReaderConfig().Format
 		if frmt == "" {
 			frmt = common.ConfigFormatYAML
 		}
-		return reader.Read(ctx, "", conf, frmt)
+		if err := reader.Read(ctx, "", conf, frmt); err != nil {
+			return nil, err
+		}
+		report.record(conf, values, SourceRemote)
+	}
+
+	if opts != nil && opts.EnvPrefix != "" {
+		if err := applyEnv(conf, opts.EnvPrefix, report); err != nil {
+			logger.Error(ctx, "error applying env overrides : %v", err)
+			return nil, err
+		}
+	}
+	if opts != nil && opts.Args != nil {
+		if err := applyFlags(conf, opts.Args, report); err != nil {
+			logger.Error(ctx, "error applying flag overrides : %v", err)
+			return nil, err
+		}
+	}
+
+	if !fields.IsStructPointer(conf) {
+		return report, nil
+	}
+	if err := ResolveSecrets(ctx, conf); err != nil {
+		logger.Error(ctx, "error resolving config secrets : %v", err)
+		return nil, err
+	}
+	if err := applyDefaults(conf, report); err != nil {
+		logger.Error(ctx, "error applying config defaults : %v", err)
+		return nil, err
 	}
-	return nil
+	if err := Validate(conf); err != nil {
+		logger.Error(ctx, "error validating config : %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// Load reads the configuration of type T from opts.Path like ReadConfigWithOptions and returns it.
+// e.g. cfg, err := configutils.Load[Config](ctx, &configutils.Options{Path: "config.yaml", EnvPrefix: "APP"})
+func Load[T any](ctx context.Context, opts *Options) (*T, error) {
+	if opts == nil || opts.Path == "" {
+		return nil, common.ErrInvalidConfig
+	}
+	conf := new(T)
+	if _, err := ReadConfigWithOptions(ctx, opts.Path, conf, opts); err != nil {
+		return nil, err
+	}
+	return conf, nil
 }
 
 // LogConfig logs the configuration.
 // It logs the configuration in yaml format if config does not implement tConfig or the format is not provided.
+// Fields tagged with secret:"true" or named like password, secret, token are masked.
 func LogConfig(ctx context.Context, conf any) {
-	if conf1, ok := conf.(tConfig); ok {
-		frmt := conf1.GetReaderConfig().Format
-		if frmt == "" {
-			frmt = common.ConfigFormatYAML
-		}
-		bytes, err := common.Marshal(conf, frmt)
-		if err != nil {
-			return
-		}
-		logger.Info(ctx, "config \n %s", string(bytes))
-	} else {
-		bytes, err := common.Marshal(conf, common.ConfigFormatYAML)
-		if err != nil {
-			return
-		}
-		logger.Info(ctx, "config \n %s", string(bytes))
+	frmt := common.ConfigFormatYAML
+	if conf1, ok := conf.(tConfig); ok && conf1.GetReaderConfig() != nil && conf1.GetReaderConfig().Format != "" {
+		frmt = conf1.GetReaderConfig().Format
+	}
+	redacted, err := redactedCopy(conf)
+	if err != nil {
+		logger.Error(ctx, "error redacting config : %v", err)
+		return
+	}
+	bytes, err := common.Marshal(redacted, frmt)
+	if err != nil {
+		logger.Error(ctx, "error marshalling config : %v", err)
+		return
 	}
+	logger.Info(ctx, "config \n %s", string(bytes))
 }
@@ -0,0 +1,261 @@
+package configutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofreego/goutils/configutils/internal/fields"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema generates the JSON Schema of the config struct T.
+// Properties are named after the yaml keys and the description, default, required, choices, min, max and pattern tags are honoured.
+// e.g. schema, err := configutils.JSONSchema[Config]()
+// Recursive struct types are defined once under $defs and referenced with $ref.
+func JSONSchema[T any]() ([]byte, error) {
+	g := &schemaGenerator{visiting: map[reflect.Type]bool{}, recursive: map[reflect.Type]bool{}, defs: map[string]any{}}
+	schema := g.typeSchema(reflect.TypeOf((*T)(nil)).Elem())
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	schema["$schema"] = jsonSchemaDraft
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// SampleYAML generates a sample yaml of the config struct T.
+// Values are taken from the default tags, the first choice or the zero value, descriptions are written as comments.
+// e.g. sample, err := configutils.SampleYAML[Config]()
+func SampleYAML[T any]() ([]byte, error) {
+	node, err := sampleNode(reflect.TypeOf((*T)(nil)).Elem(), "", map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
+
+// schemaGenerator generates the schemas of the types, it keeps the struct types being generated to detect the recursive ones.
+type schemaGenerator struct {
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defs      map[string]any
+}
+
+// defName returns the name of the recursive struct type t under $defs.
+func defName(t reflect.Type) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, t.String())
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == fields.DurationType:
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + defName(t)}
+		if g.visiting[t] {
+			g.recursive[t] = true
+			return ref
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+		properties := map[string]any{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := fields.Name(field)
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			properties[name] = g.fieldSchema(field)
+			if field.Tag.Get("required") == "true" {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		if g.recursive[t] {
+			g.defs[defName(t)] = schema
+			return ref
+		}
+		return schema
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+// fieldSchema returns the schema of the field type, extended with the constraints from the field tags.
+// The choices of slices and arrays are the enum of their items.
+func (g *schemaGenerator) fieldSchema(field reflect.StructField) map[string]any {
+	schema := g.typeSchema(field.Type)
+	t := field.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if description, ok := field.Tag.Lookup("description"); ok {
+		schema["description"] = description
+	}
+	if field.Tag.Get("secret") == "true" {
+		schema["writeOnly"] = true
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		schema["default"] = typedValue(t, def)
+	}
+	if choices, ok := field.Tag.Lookup("choices"); ok {
+		enumSchema, enumType := schema, t
+		if items, ok := schema["items"].(map[string]any); ok && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			enumSchema, enumType = items, t.Elem()
+		}
+		var enum []any
+		for _, choice := range strings.Split(choices, ",") {
+			enum = append(enum, typedValue(enumType, strings.TrimSpace(choice)))
+		}
+		enumSchema["enum"] = enum
+	}
+	if pattern, ok := field.Tag.Lookup("pattern"); ok && t.Kind() == reflect.String {
+		schema["pattern"] = pattern
+	}
+	for _, tag := range []string{"min", "max"} {
+		bound, ok := field.Tag.Lookup(tag)
+		if !ok || t == fields.DurationType {
+			continue
+		}
+		n, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			continue
+		}
+		switch t.Kind() {
+		case reflect.String:
+			schema[tag+"Length"] = n
+		case reflect.Slice, reflect.Array:
+			schema[tag+"Items"] = n
+		case reflect.Map:
+			schema[tag+"Properties"] = n
+		default:
+			if tag == "min" {
+				schema["minimum"] = n
+			} else {
+				schema["maximum"] = n
+			}
+		}
+	}
+	return schema
+}
+
+// typedValue converts the tag value s into a value of type t, it returns s if it can not be converted.
+func typedValue(t reflect.Type, s string) any {
+	if t == fields.DurationType || !fields.IsScalar(t) {
+		return s
+	}
+	v := reflect.New(t).Elem()
+	if err := fields.SetFromString(v, s); err != nil {
+		return s
+	}
+	return v.Interface()
+}
+
+// sampleNode returns the yaml node with the sample value of type t.
+// def is the value of the default tag, if any.
+// visiting holds the struct types being sampled, a recursive struct type is sampled empty.
+func sampleNode(t reflect.Type, def string, visiting map[reflect.Type]bool) (*yaml.Node, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t != timeType {
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		if visiting[t] {
+			node.Style = yaml.FlowStyle
+			return node, nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := fields.Name(field)
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			value, ok := field.Tag.Lookup("default")
+			if !ok {
+				if choices, found := field.Tag.Lookup("choices"); found {
+					value, _, _ = strings.Cut(choices, ",")
+				}
+			}
+			valueNode, err := sampleNode(field.Type, value, visiting)
+			if err != nil {
+				return nil, err
+			}
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+			if description, found := field.Tag.Lookup("description"); found {
+				keyNode.HeadComment = description
+			}
+			node.Content = append(node.Content, keyNode, valueNode)
+		}
+		return node, nil
+	}
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && !fields.IsScalar(t) {
+		item, err := sampleNode(t.Elem(), "", visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{item}}, nil
+	}
+
+	var value any = reflect.Zero(t).Interface()
+	if def != "" {
+		value = typedValue(t, def)
+	}
+	if t == fields.DurationType {
+		value = time.Duration(0).String()
+		if def != "" {
+			value = def
+		}
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
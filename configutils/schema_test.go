@@ -0,0 +1,73 @@
+package configutils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type schemaNode struct {
+	Name     string        `yaml:"Name" description:"Name of the node"`
+	Children []*schemaNode `yaml:"Children"`
+}
+
+type schemaConfig struct {
+	Build    string     `yaml:"Build" choices:"prod,dev"`
+	Patterns []string   `yaml:"Patterns" choices:"email,mobile"`
+	Ports    []int      `yaml:"Ports" choices:"80,443" min:"1"`
+	Tree     schemaNode `yaml:"Tree"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	bytes, err := JSONSchema[schemaConfig]()
+	if err != nil {
+		t.Fatalf("JSONSchema() = %v", err)
+	}
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Defs       map[string]map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal(bytes, &schema); err != nil {
+		t.Fatalf("invalid json schema : %v", err)
+	}
+
+	if got, want := schema.Properties["Build"]["enum"], []any{"prod", "dev"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Build enum = %v, want %v", got, want)
+	}
+	for name, want := range map[string][]any{"Patterns": {"email", "mobile"}, "Ports": {float64(80), float64(443)}} {
+		property := schema.Properties[name]
+		if _, ok := property["enum"]; ok {
+			t.Fatalf("%s has an enum on the array", name)
+		}
+		if got := property["items"].(map[string]any)["enum"]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s items enum = %v, want %v", name, got, want)
+		}
+	}
+	if got := schema.Properties["Ports"]["minItems"]; got != float64(1) {
+		t.Fatalf("Ports minItems = %v, want 1", got)
+	}
+
+	ref := "#/$defs/configutils.schemaNode"
+	if got := schema.Properties["Tree"]["$ref"]; got != ref {
+		t.Fatalf("Tree $ref = %v, want %s", got, ref)
+	}
+	def, ok := schema.Defs["configutils.schemaNode"]
+	if !ok {
+		t.Fatalf("$defs = %v, want configutils.schemaNode", schema.Defs)
+	}
+	children := def["properties"].(map[string]any)["Children"].(map[string]any)
+	if got := children["items"].(map[string]any)["$ref"]; got != ref {
+		t.Fatalf("Children items $ref = %v, want %s", got, ref)
+	}
+}
+
+func TestSampleYAMLRecursive(t *testing.T) {
+	sample, err := SampleYAML[schemaConfig]()
+	if err != nil {
+		t.Fatalf("SampleYAML() = %v", err)
+	}
+	if !strings.Contains(string(sample), "Build: prod") || !strings.Contains(string(sample), "Children:") {
+		t.Fatalf("SampleYAML() = %s", sample)
+	}
+}
@@ -0,0 +1,178 @@
+package configutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/configutils/internal/fields"
+)
+
+const (
+	// secretMask replaces the secret values when the configuration is logged
+	secretMask = "******"
+)
+
+// SecretProvider resolves the secret references of the form ${name:key}, where name is the name the provider is registered with.
+// Implement it to resolve secrets from a secret store like vault and register it with RegisterSecretProvider.
+type SecretProvider interface {
+	// Resolve returns the secret value for the given key.
+	Resolve(ctx context.Context, key string) (string, error)
+}
+
+// EnvSecretProvider resolves ${env:NAME} from the environment variable NAME.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) Resolve(ctx context.Context, key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("env %s is not set", key)
+	}
+	return value, nil
+}
+
+// FileSecretProvider resolves ${file:/run/secrets/name} from the content of the file, trailing new lines are trimmed.
+type FileSecretProvider struct{}
+
+func (FileSecretProvider) Resolve(ctx context.Context, key string) (string, error) {
+	bytes, err := os.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bytes), "\r\n"), nil
+}
+
+var (
+	secretRefRegex      = regexp.MustCompile(`\$\{([a-zA-Z0-9_-]+):([^}]+)\}`)
+	secretNames         = []string{"password", "passwd", "secret", "token", "apikey", "privatekey"}
+	secretProvidersLock sync.RWMutex
+	secretProviders     = map[string]SecretProvider{
+		"env":  EnvSecretProvider{},
+		"file": FileSecretProvider{},
+	}
+)
+
+// RegisterSecretProvider registers a provider for the secret references ${name:key}.
+// It replaces the provider already registered with the same name.
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersLock.Lock()
+	defer secretProvidersLock.Unlock()
+	secretProviders[name] = provider
+}
+
+func getSecretProvider(name string) (SecretProvider, bool) {
+	secretProvidersLock.RLock()
+	defer secretProvidersLock.RUnlock()
+	provider, ok := secretProviders[name]
+	return provider, ok
+}
+
+// ResolveSecrets replaces the secret references in every string field of conf with the resolved secret.
+// e.g. Password: ${env:DB_PASS} or Password: ${file:/run/secrets/db_pass}
+// conf must be a pointer to struct.
+func ResolveSecrets(ctx context.Context, conf any) error {
+	return fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		switch {
+		case value.Kind() == reflect.String:
+			return resolveSecretValue(ctx, path, value)
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+			for i := 0; i < value.Len(); i++ {
+				if err := resolveSecretValue(ctx, path, value.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func resolveSecretValue(ctx context.Context, path []string, value reflect.Value) error {
+	str := value.String()
+	if !strings.Contains(str, "${") {
+		return nil
+	}
+	var resolveErr error
+	resolved := secretRefRegex.ReplaceAllStringFunc(str, func(ref string) string {
+		match := secretRefRegex.FindStringSubmatch(ref)
+		provider, ok := getSecretProvider(match[1])
+		if !ok {
+			resolveErr = fmt.Errorf("unknown secret provider %s for %s", match[1], strings.Join(path, "."))
+			return ref
+		}
+		secret, err := provider.Resolve(ctx, match[2])
+		if err != nil {
+			resolveErr = fmt.Errorf("failed to resolve secret for %s, Err: %w", strings.Join(path, "."), err)
+			return ref
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return resolveErr
+	}
+	value.SetString(resolved)
+	return nil
+}
+
+// isSecretField reports whether the field is tagged with secret:"true" or its name looks like a secret, e.g. Password, Token.
+func isSecretField(path []string, field reflect.StructField) bool {
+	if field.Tag.Get("secret") == "true" {
+		return true
+	}
+	for _, name := range []string{field.Name, path[len(path)-1]} {
+		name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+		for _, secretName := range secretNames {
+			if strings.Contains(name, secretName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redact masks every secret field of conf, see isSecretField.
+// conf must be a pointer to struct.
+func redact(conf any) error {
+	return fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		if !isSecretField(path, field) || value.IsZero() {
+			return nil
+		}
+		if value.Kind() == reflect.String {
+			value.SetString(secretMask)
+		} else {
+			value.Set(reflect.Zero(value.Type()))
+		}
+		return nil
+	})
+}
+
+// redactedCopy returns a copy of conf with every secret field masked.
+// The copy is made through a yaml round trip, conf is returned as it is if it is not a struct.
+func redactedCopy(conf any) (any, error) {
+	v := reflect.ValueOf(conf)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return conf, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return conf, nil
+	}
+	bytes, err := common.Marshal(conf, common.ConfigFormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	cp := reflect.New(v.Type()).Interface()
+	if err := common.Unmarshal(bytes, cp, common.ConfigFormatYAML); err != nil {
+		return nil, err
+	}
+	if err := redact(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
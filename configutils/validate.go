@@ -0,0 +1,157 @@
+package configutils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofreego/goutils/configutils/internal/fields"
+)
+
+// ValidationError lists every violation found while validating a configuration.
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config, violations: " + strings.Join(e.Violations, "; ")
+}
+
+// ApplyDefaults sets the value of the default tag on every zero valued field of conf.
+// conf must be a pointer to struct.
+// e.g. MaxOpenConns int `yaml:"MaxOpenConns" default:"20"`
+func ApplyDefaults(conf any) error {
+	return applyDefaults(conf, Report{})
+}
+
+func applyDefaults(conf any, report Report) error {
+	return fields.ApplyDefaults(conf, func(path []string) {
+		report[strings.Join(path, ".")] = SourceDefault
+	})
+}
+
+// Validate validates conf against the tags of its fields and returns a *ValidationError listing every violation.
+// conf must be a pointer to struct.
+// Supported tags:
+// required:"true" : field must not be zero
+// choices:"prod,dev" : field must be one of the choices, if set
+// min:"1" max:"10" : bounds for numbers and durations, bounds on length for strings, slices and maps
+// pattern:"^[a-z]+$" : string field must match the regular expression, if set
+func Validate(conf any) error {
+	var violations []string
+	err := fields.Walk(conf, func(path []string, field reflect.StructField, value reflect.Value) error {
+		for _, violation := range validateField(field, value) {
+			violations = append(violations, strings.Join(path, ".")+" "+violation)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func validateField(field reflect.StructField, value reflect.Value) []string {
+	if field.Tag.Get("required") == "true" && value.IsZero() {
+		return []string{"is required"}
+	}
+	if value.IsZero() {
+		return nil
+	}
+
+	var violations []string
+	if choices, ok := field.Tag.Lookup("choices"); ok {
+		violations = append(violations, checkChoices(value, choices)...)
+	}
+	if min, ok := field.Tag.Lookup("min"); ok {
+		if violation := checkBound(value, min, true); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+	if max, ok := field.Tag.Lookup("max"); ok {
+		if violation := checkBound(value, max, false); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+	if pattern, ok := field.Tag.Lookup("pattern"); ok && value.Kind() == reflect.String {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("has invalid pattern %s, Err: %s", pattern, err.Error()))
+		} else if !re.MatchString(value.String()) {
+			violations = append(violations, fmt.Sprintf("must match pattern %s", pattern))
+		}
+	}
+	return violations
+}
+
+// checkChoices checks value against the comma separated choices and returns the violations if any.
+// Every element of slices and arrays must be one of the choices.
+func checkChoices(value reflect.Value, choices string) []string {
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Type().Elem().Kind() != reflect.Uint8 {
+		var violations []string
+		for i := 0; i < value.Len(); i++ {
+			if violation := checkChoice(value.Index(i), choices); violation != "" {
+				violations = append(violations, fmt.Sprintf("[%d] %s", i, violation))
+			}
+		}
+		return violations
+	}
+	if violation := checkChoice(value, choices); violation != "" {
+		return []string{violation}
+	}
+	return nil
+}
+
+func checkChoice(value reflect.Value, choices string) string {
+	str := fields.String(value)
+	for _, choice := range strings.Split(choices, ",") {
+		if strings.TrimSpace(choice) == str {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of [%s], got %s", choices, str)
+}
+
+// checkBound checks value against the min or max bound and returns the violation if any.
+func checkBound(value reflect.Value, bound string, isMin bool) string {
+	var actual, limit float64
+	var err error
+	what := "must be"
+	switch {
+	case value.Type() == fields.DurationType:
+		var d time.Duration
+		d, err = time.ParseDuration(bound)
+		actual, limit = float64(value.Int()), float64(d)
+	case value.Kind() == reflect.String || value.Kind() == reflect.Slice || value.Kind() == reflect.Map:
+		limit, err = strconv.ParseFloat(bound, 64)
+		actual = float64(value.Len())
+		what = "length must be"
+	case value.CanInt():
+		limit, err = strconv.ParseFloat(bound, 64)
+		actual = float64(value.Int())
+	case value.CanUint():
+		limit, err = strconv.ParseFloat(bound, 64)
+		actual = float64(value.Uint())
+	case value.CanFloat():
+		limit, err = strconv.ParseFloat(bound, 64)
+		actual = value.Float()
+	default:
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("has invalid bound %s, Err: %s", bound, err.Error())
+	}
+	if isMin && actual < limit {
+		return fmt.Sprintf("%s >= %s", what, bound)
+	}
+	if !isMin && actual > limit {
+		return fmt.Sprintf("%s <= %s", what, bound)
+	}
+	return ""
+}
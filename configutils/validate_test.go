@@ -0,0 +1,71 @@
+package configutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofreego/goutils/logger"
+)
+
+type validateConfig struct {
+	Name     string        `yaml:"Name" required:"true" pattern:"^[a-z]+$"`
+	Build    string        `yaml:"Build" choices:"prod,dev"`
+	Port     int           `yaml:"Port" min:"1" max:"65535"`
+	Timeout  time.Duration `yaml:"Timeout" min:"1s"`
+	Patterns []string      `yaml:"Patterns" choices:"email,mobile,card,bearer,none"`
+	Hosts    []string      `yaml:"Hosts" min:"1"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *validateConfig {
+		return &validateConfig{Name: "app", Build: "prod", Port: 8080, Timeout: time.Second, Patterns: []string{"email", "card"}, Hosts: []string{"a"}}
+	}
+	tests := []struct {
+		name       string
+		modify     func(c *validateConfig)
+		violations []string
+	}{
+		{name: "valid", modify: func(c *validateConfig) {}},
+		{name: "zero optional fields", modify: func(c *validateConfig) { c.Build, c.Port, c.Timeout, c.Patterns, c.Hosts = "", 0, 0, nil, nil }},
+		{name: "required", modify: func(c *validateConfig) { c.Name = "" }, violations: []string{"Name is required"}},
+		{name: "pattern", modify: func(c *validateConfig) { c.Name = "App" }, violations: []string{"Name must match pattern ^[a-z]+$"}},
+		{name: "choices", modify: func(c *validateConfig) { c.Build = "test" }, violations: []string{"Build must be one of [prod,dev], got test"}},
+		{name: "bounds", modify: func(c *validateConfig) { c.Port, c.Timeout = 70000, time.Millisecond }, violations: []string{"Port must be <= 65535", "Timeout must be >= 1s"}},
+		{name: "slice choices", modify: func(c *validateConfig) { c.Patterns = []string{"email", "phone", "card", "ssn"} },
+			violations: []string{
+				"Patterns [1] must be one of [email,mobile,card,bearer,none], got phone",
+				"Patterns [3] must be one of [email,mobile,card,bearer,none], got ssn",
+			}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := valid()
+			test.modify(conf)
+			err := Validate(conf)
+			if test.violations == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Violations, test.violations) {
+				t.Fatalf("Violations = %q, want %q", validationErr.Violations, test.violations)
+			}
+		})
+	}
+}
+
+func TestValidateRedactionPatterns(t *testing.T) {
+	if err := Validate(&logger.RedactionConfig{Patterns: []string{"email", "bearer"}}); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	if err := Validate(&logger.RedactionConfig{Patterns: []string{"email", "phone"}}); err == nil {
+		t.Fatal("Validate() = nil, want the violation of phone")
+	}
+}
@@ -10,4 +10,6 @@ const (
 	HEADER_USER_UUID     = "x-user-uuid"
 	HEADER_PROFILE_IDS   = "x-profile-ids"
 	HEADER_AUTHORIZATION = "authorization"
+	HEADER_CLIENT_ID     = "x-client-id"
+	HEADER_USER_AGENT    = "user-agent"
 )
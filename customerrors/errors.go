@@ -3,14 +3,27 @@ package customerrors
 import (
 	"fmt"
 	"net/http"
+	"runtime"
 )
 
 type Error struct {
 	message string
 	code    int
+	// cause is the error wrapped by Wrap, stack is the stack trace of the Wrap call
+	cause error
+	stack []uintptr
 }
 
+// Error returns the message followed by the cause for the logs, the responses to the clients use Message.
 func (e *Error) Error() string {
+	if e.cause == nil {
+		return e.message
+	}
+	return e.message + ", Err: " + e.cause.Error()
+}
+
+// Message returns the message of the error without the cause, it is safe to return to the clients.
+func (e *Error) Message() string {
 	return e.message
 }
 
@@ -18,6 +31,24 @@ func (e *Error) Code() int {
 	return e.code
 }
 
+// HTTPStatus returns the code if it is an http status, else 500, e.g. for the database error codes.
+func (e *Error) HTTPStatus() int {
+	if e.code >= 100 && e.code <= 599 {
+		return e.code
+	}
+	return http.StatusInternalServerError
+}
+
+// Unwrap returns the error wrapped by Wrap, so that errors.Is and errors.As see it.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// StackTrace returns the program counters of the stack trace captured by Wrap, nil for the other errors.
+func (e *Error) StackTrace() []uintptr {
+	return e.stack
+}
+
 var (
 	ERROR_UNAUTHORISED              = &Error{message: "unauthorised", code: http.StatusUnauthorized}
 	ERROR_DATABASE                  = &Error{message: "database operation failed", code: http.StatusFailedDependency}
@@ -34,3 +65,12 @@ func BAD_REQUEST_ERROR(message string, args ...any) error {
 func New(code int, message string, args ...any) error {
 	return &Error{code: code, message: fmt.Sprintf(message, args...)}
 }
+
+// Wrap returns an error of the code wrapping err, its Error is followed by the message of err,
+// e.g. "failed to create order, Err: connection refused", its Message is only "failed to create order".
+// The stack trace of the call is captured, logger.Err writes it.
+func Wrap(err error, code int, message string, args ...any) error {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	return &Error{code: code, message: fmt.Sprintf(message, args...), cause: err, stack: pcs[:n]}
+}
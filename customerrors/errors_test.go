@@ -0,0 +1,30 @@
+package customerrors
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Wrap(cause, http.StatusNotFound, "order %d not found", 1)
+
+	var customErr *Error
+	if !errors.As(err, &customErr) || !errors.Is(err, cause) {
+		t.Fatalf("Wrap() = %#v, want a *Error wrapping the cause", err)
+	}
+	if got, want := err.Error(), "order 1 not found, Err: connection refused"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+	if got, want := customErr.Message(), "order 1 not found"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+	st, _ := status.FromError(err)
+	if st.Code() != codes.NotFound || st.Message() != "order 1 not found" {
+		t.Fatalf("GRPCStatus() = %v", st)
+	}
+}
@@ -0,0 +1,42 @@
+package customerrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpStatusGRPCCodes maps the http statuses to the grpc codes, the inverse of the grpc-gateway mapping.
+var httpStatusGRPCCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	499:                            codes.Canceled,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// GRPCCode returns the grpc code of the http status of the error, InvalidArgument for the other 4xx and Internal for the other 5xx.
+func (e *Error) GRPCCode() codes.Code {
+	status := e.HTTPStatus()
+	if code, ok := httpStatusGRPCCodes[status]; ok {
+		return code
+	}
+	if status >= 400 && status < 500 {
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+// GRPCStatus returns the grpc status of the error, status.FromError uses it so that the grpc servers return the code of the error.
+// The status has the Message of the error, the cause is not sent to the clients.
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode(), e.Message())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gofreego/goutils/configutils/defaults"
 	"github.com/gofreego/goutils/customerrors"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -13,43 +14,28 @@ import (
 type Config struct {
 	Hosts                  string        `yaml:"Host"`
 	Username               string        `yaml:"Username"`
-	Password               string        `yaml:"Password"`
+	Password               string        `yaml:"Password" secret:"true"`
 	Database               string        `yaml:"Database"`
-	MaxPoolSize            uint64        `yaml:"MaxPoolSize"`
-	MinPoolSize            uint64        `yaml:"MinPoolSize"`
-	MaxConnIdleTime        time.Duration `yaml:"MaxConnIdleTime"`
-	MaxConnecting          uint64        `yaml:"MaxConnecting"`
-	ConnectTimeout         time.Duration `yaml:"ConnectTimeout"`
-	ServerSelectionTimeout time.Duration `yaml:"ServerSelectionTimeout"`
+	MaxPoolSize            uint64        `yaml:"MaxPoolSize" default:"100"`
+	MinPoolSize            uint64        `yaml:"MinPoolSize" default:"5"`
+	MaxConnIdleTime        time.Duration `yaml:"MaxConnIdleTime" default:"30m"`
+	MaxConnecting          uint64        `yaml:"MaxConnecting" default:"10"`
+	ConnectTimeout         time.Duration `yaml:"ConnectTimeout" default:"10s"`
+	ServerSelectionTimeout time.Duration `yaml:"ServerSelectionTimeout" default:"30s"`
 	Direct                 bool          `yaml:"Direct"`
 	ReplicaSet             string        `yaml:"ReplicaSet"`
 }
 
-// setDefaultPoolConfig sets default values for connection pool configuration
-func (cfg *Config) withDefault() {
-	if cfg.MaxPoolSize == 0 {
-		cfg.MaxPoolSize = 100 // Default max pool size
-	}
-	if cfg.MinPoolSize == 0 {
-		cfg.MinPoolSize = 5 // Default min pool size
-	}
-	if cfg.MaxConnIdleTime == 0 {
-		cfg.MaxConnIdleTime = 30 * time.Minute // Default max idle time
-	}
-	if cfg.MaxConnecting == 0 {
-		cfg.MaxConnecting = 10 // Default max connecting
-	}
-	if cfg.ConnectTimeout == 0 {
-		cfg.ConnectTimeout = 10 * time.Second // Default connect timeout
-	}
-	if cfg.ServerSelectionTimeout == 0 {
-		cfg.ServerSelectionTimeout = 30 * time.Second // Default server selection timeout
-	}
+// withDefault sets the default tags of the connection pool configuration on the zero valued fields
+func (cfg *Config) withDefault() error {
+	return defaults.Apply(cfg)
 }
 
 func NewMongoConnection(ctx context.Context, cfg *Config) (*mongo.Client, error) {
 	// Set default pool configuration if not provided
-	cfg.withDefault()
+	if err := cfg.withDefault(); err != nil {
+		return nil, customerrors.New(customerrors.ERROR_CODE_DATABASE_INVALID_CONFIGURATION, "invalid mongo configuration, Err: %s", err.Error())
+	}
 
 	clientOptions := options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:%s@%s/?replicaSet=%s", cfg.Username, cfg.Password, cfg.Hosts, cfg.ReplicaSet))
 
@@ -5,29 +5,24 @@ import (
 	"fmt"
 
 	_ "github.com/ClickHouse/clickhouse-go/v2" // registers "clickhouse" database/sql driver
+	"github.com/gofreego/goutils/configutils/defaults"
 	"github.com/gofreego/goutils/customerrors"
 )
 
 type Config struct {
 	Host         string `yaml:"Host"`
-	Port         int    `yaml:"Port"`
+	Port         int    `yaml:"Port" default:"9000"`
 	Username     string `yaml:"Username"`
-	Password     string `yaml:"Password"`
+	Password     string `yaml:"Password" secret:"true"`
 	Database     string `yaml:"Database"`
-	MaxOpenConns int    `yaml:"MaxOpenConns"`
-	MaxIdleConns int    `yaml:"MaxIdleConns"`
+	MaxOpenConns int    `yaml:"MaxOpenConns" default:"10"`
+	MaxIdleConns int    `yaml:"MaxIdleConns" default:"5"`
 }
 
-func (c *Config) WithDefaults() {
-	if c.Port == 0 {
-		c.Port = 9000
-	}
-	if c.MaxOpenConns == 0 {
-		c.MaxOpenConns = 10
-	}
-	if c.MaxIdleConns == 0 {
-		c.MaxIdleConns = 5
-	}
+// WithDefaults sets the default tags on the zero valued fields.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	return defaults.Apply(c)
 }
 
 // GetConnection opens a ClickHouse database/sql connection.
@@ -36,7 +31,9 @@ func GetConnection(cfg *Config) (*sql.DB, error) {
 	if cfg == nil {
 		return nil, customerrors.New(customerrors.ERROR_CODE_DATABASE_INVALID_CONFIGURATION, "configuration cannot be nil")
 	}
-	cfg.WithDefaults()
+	if err := cfg.WithDefaults(); err != nil {
+		return nil, customerrors.New(customerrors.ERROR_CODE_DATABASE_INVALID_CONFIGURATION, "invalid clickhouse configuration, Err: %s", err.Error())
+	}
 
 	dsn := fmt.Sprintf("tcp://%s:%d?username=%s&password=%s&database=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database)
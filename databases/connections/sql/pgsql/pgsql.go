@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gofreego/goutils/configutils/defaults"
 	"github.com/gofreego/goutils/customerrors"
 	_ "github.com/lib/pq"
 )
@@ -13,40 +14,30 @@ type Config struct {
 	Host     string `yaml:"Host"`
 	Port     int    `yaml:"Port"`
 	Username string `yaml:"Username"`
-	Password string `yaml:"Password"`
+	Password string `yaml:"Password" secret:"true"`
 	DBName   string `yaml:"DBName"`
-	SSLMode  string `yaml:"SSLMode"`
+	SSLMode  string `yaml:"SSLMode" default:"disable"`
 
 	// Connection Pool Settings
-	MaxOpenConns    int           `yaml:"MaxOpenConns"`    // Maximum number of open connections
-	MaxIdleConns    int           `yaml:"MaxIdleConns"`    // Maximum number of idle connections
-	ConnMaxLifetime time.Duration `yaml:"ConnMaxLifetime"` // Maximum amount of time a connection may be reused
-	ConnMaxIdleTime time.Duration `yaml:"ConnMaxIdleTime"` // Maximum amount of time a connection may be idle
+	MaxOpenConns    int           `yaml:"MaxOpenConns" default:"20"`     // Maximum number of open connections
+	MaxIdleConns    int           `yaml:"MaxIdleConns" default:"10"`     // Maximum number of idle connections
+	ConnMaxLifetime time.Duration `yaml:"ConnMaxLifetime" default:"30m"` // Maximum amount of time a connection may be reused
+	ConnMaxIdleTime time.Duration `yaml:"ConnMaxIdleTime" default:"5m"`  // Maximum amount of time a connection may be idle
 }
 
-func (c *Config) WithDefaults() {
-	if c.MaxOpenConns == 0 {
-		c.MaxOpenConns = 20 // Default value
-	}
-	if c.MaxIdleConns == 0 {
-		c.MaxIdleConns = 10 // Default value
-	}
-	if c.ConnMaxLifetime == 0 {
-		c.ConnMaxLifetime = 30 * time.Minute // Default value
-	}
-	if c.ConnMaxIdleTime == 0 {
-		c.ConnMaxIdleTime = 5 * time.Minute // Default value
-	}
-	if c.SSLMode == "" {
-		c.SSLMode = "disable" // Default SSL mode
-	}
+// WithDefaults sets the default tags on the zero valued fields.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	return defaults.Apply(c)
 }
 
 func GetConnection(cfg *Config) (*sql.DB, error) {
 	if cfg == nil {
 		return nil, customerrors.New(customerrors.ERROR_CODE_DATABASE_INVALID_CONFIGURATION, "configuration cannot be nil")
 	}
-	cfg.WithDefaults()
+	if err := cfg.WithDefaults(); err != nil {
+		return nil, customerrors.New(customerrors.ERROR_CODE_DATABASE_INVALID_CONFIGURATION, "invalid postgresql configuration, Err: %s", err.Error())
+	}
 	connStr := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.DBName, cfg.SSLMode,
@@ -33,7 +33,7 @@ const (
 type Config struct {
 	Path         string                 `yaml:"Path" json:"path"`
 	DBType       databases.DatabaseName `yaml:"DBType" json:"dbType"`
-	Action       Action                 `yaml:"Action" json:"action"`
+	Action       Action                 `yaml:"Action" json:"action" choices:"up,down,migrate_to,force,version"`
 	ForceVersion int                    `yaml:"ForceVersion" json:"forceVersion"`
 }
 
@@ -0,0 +1,201 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofreego/goutils/configutils"
+	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/logger"
+)
+
+// Config represents the configuration of the feature flags client.
+// Path : path of the flag definitions in the config reader, the document maps the flag names to their definitions
+// Format : format of the flag definitions, default yaml
+// RefreshInterval : interval to reload the definitions when the config reader does not support watching, default 30s
+// DisableEvaluationLogs : disables the log line written for every evaluation
+type Config struct {
+	Path                  string                  `yaml:"Path" required:"true"`
+	Format                common.ConfigFormatType `yaml:"Format" default:"yaml"`
+	RefreshInterval       time.Duration           `yaml:"RefreshInterval" default:"30s"`
+	DisableEvaluationLogs bool                    `yaml:"DisableEvaluationLogs"`
+}
+
+// WithDefaults sets the default tags on the zero valued fields.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	return configutils.ApplyDefaults(c)
+}
+
+// Client evaluates the feature flags locally from the definitions cached in memory.
+// Definitions are read from a config reader, consul, zookeeper or file, and watched if the reader implements configutils.Watcher, else reloaded every RefreshInterval.
+// Invalid definitions are logged and the previous definitions are kept.
+type Client struct {
+	reader configutils.ConfigReader
+	conf   *Config
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.RWMutex
+	flags  map[string]*compiledFlag
+}
+
+// NewClient loads the flag definitions and starts watching them for changes until Close is called.
+// A missing document is not an error, every flag evaluates to the default passed by the caller then.
+func NewClient(ctx context.Context, reader configutils.ConfigReader, conf *Config) (*Client, error) {
+	if reader == nil || conf == nil || conf.Path == "" {
+		return nil, common.ErrInvalidConfig
+	}
+	if err := conf.WithDefaults(); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		reader: reader,
+		conf:   conf,
+		done:   make(chan struct{}),
+		flags:  map[string]*compiledFlag{},
+	}
+	if err := c.Reload(ctx); err != nil {
+		return nil, err
+	}
+	ctx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	go c.watch(ctx)
+	return c, nil
+}
+
+// Close stops watching the flag definitions.
+func (c *Client) Close() {
+	c.cancel()
+	<-c.done
+}
+
+// Reload reads the flag definitions from the config reader.
+func (c *Client) Reload(ctx context.Context) error {
+	definitions := map[string]*Flag{}
+	err := c.reader.Read(ctx, c.conf.Path, &definitions, c.conf.Format)
+	if errors.Is(err, common.ErrConfigNotFound) {
+		logger.Warn(ctx, "feature flags not found at path : %s", c.conf.Path)
+		err = nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Error reading feature flags from path : %s, err : %v", c.conf.Path, err)
+		return err
+	}
+	return c.apply(definitions)
+}
+
+// apply validates the definitions and replaces the cached flags.
+func (c *Client) apply(definitions map[string]*Flag) error {
+	flags := make(map[string]*compiledFlag, len(definitions))
+	for name, definition := range definitions {
+		if definition == nil {
+			continue
+		}
+		flag, err := compile(name, definition)
+		if err != nil {
+			return err
+		}
+		flags[name] = flag
+	}
+	c.mu.Lock()
+	c.flags = flags
+	c.mu.Unlock()
+	return nil
+}
+
+// Evaluate evaluates the flag for the user of the context, see UserIDFromContext.
+// Evaluation.Value is nil if the flag is not defined.
+func (c *Client) Evaluate(ctx context.Context, name string) Evaluation {
+	c.mu.RLock()
+	flag, ok := c.flags[name]
+	c.mu.RUnlock()
+
+	evaluation := Evaluation{Flag: name, Reason: ReasonNotFound}
+	if ok {
+		evaluation.Variant, evaluation.Reason = flag.evaluate(name, UserIDFromContext(ctx))
+		evaluation.Value = flag.Variants[evaluation.Variant]
+	}
+	recordEvaluation(ctx, evaluation)
+	if !c.conf.DisableEvaluationLogs {
+		logger.Infow(ctx, "feature flag evaluated", logger.NewFields().
+			AddField("flag", evaluation.Flag).
+			AddField("variant", evaluation.Variant).
+			AddField("reason", string(evaluation.Reason)))
+	}
+	return evaluation
+}
+
+// Bool returns the boolean value of the flag, def if the flag is not defined or its value is not a boolean.
+func (c *Client) Bool(ctx context.Context, name string, def bool) bool {
+	if value, ok := c.Evaluate(ctx, name).Value.(bool); ok {
+		return value
+	}
+	return def
+}
+
+// String returns the string value of the flag, def if the flag is not defined or its value is not a string.
+func (c *Client) String(ctx context.Context, name string, def string) string {
+	if value, ok := c.Evaluate(ctx, name).Value.(string); ok {
+		return value
+	}
+	return def
+}
+
+// Value returns the value of a multivariate flag converted to T, def if the flag is not defined or its value can not be converted.
+// e.g. limits := featureflags.Value(ctx, client, "rateLimits", DefaultLimits)
+func Value[T any](ctx context.Context, c *Client, name string, def T) T {
+	evaluation := c.Evaluate(ctx, name)
+	if evaluation.Value == nil {
+		return def
+	}
+	if value, ok := evaluation.Value.(T); ok {
+		return value
+	}
+	bytes, err := json.Marshal(evaluation.Value)
+	if err != nil {
+		return def
+	}
+	var value T
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		logger.Error(ctx, "Error converting value of feature flag : %s, err : %v", name, err)
+		return def
+	}
+	return value
+}
+
+// watch keeps the flags up to date until ctx is done.
+func (c *Client) watch(ctx context.Context) {
+	defer close(c.done)
+	if watcher, ok := c.reader.(configutils.Watcher); ok {
+		_ = watcher.Watch(ctx, c.conf.Path, func(data []byte) {
+			definitions := map[string]*Flag{}
+			// a deleted document removes every flag, as Reload does
+			if data == nil {
+				logger.Warn(ctx, "feature flags not found at path : %s", c.conf.Path)
+				_ = c.apply(definitions)
+				return
+			}
+			err := common.Unmarshal(data, &definitions, c.conf.Format)
+			if err == nil {
+				err = c.apply(definitions)
+			}
+			if err != nil {
+				logger.Error(ctx, "Error loading feature flags from path : %s, err : %v", c.conf.Path, err)
+			}
+		})
+		return
+	}
+
+	ticker := time.NewTicker(c.conf.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = c.Reload(ctx)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package featureflags
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/logger"
+	"github.com/gofreego/goutils/logger/logtest"
+)
+
+// watchingReader is an in-memory config reader whose watch is fed by the changes channel, a nil document is missing.
+type watchingReader struct {
+	mu      sync.Mutex
+	data    []byte
+	changes chan []byte
+	// applied receives a value after every call to onChange
+	applied chan struct{}
+}
+
+func (r *watchingReader) Read(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return common.ErrConfigNotFound
+	}
+	return common.Unmarshal(r.data, conf, configFormat...)
+}
+
+func (r *watchingReader) Update(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	return nil
+}
+
+func (r *watchingReader) Watch(ctx context.Context, path string, onChange func(data []byte)) error {
+	for {
+		select {
+		case data := <-r.changes:
+			onChange(data)
+			r.applied <- struct{}{}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// change feeds the document to the watch and waits until the client applied it.
+func (r *watchingReader) change(t *testing.T, data []byte) {
+	t.Helper()
+	r.changes <- data
+	select {
+	case <-r.applied:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the change was not applied")
+	}
+}
+
+const testFlags = `
+checkout:
+    Enabled: true
+    Deny: [u2]
+theme:
+    Enabled: true
+    Variants: {dark: "#000", light: "#fff"}
+    OnVariant: dark
+    OffVariant: light
+limits:
+    Enabled: true
+    Variants:
+        on: {max: 100}
+        off: {max: 10}
+`
+
+type limits struct {
+	Max int `json:"max"`
+}
+
+func newTestClient(t *testing.T, data string) (*Client, *watchingReader) {
+	t.Helper()
+	reader := &watchingReader{changes: make(chan []byte), applied: make(chan struct{})}
+	if data != "" {
+		reader.data = []byte(data)
+	}
+	client, err := NewClient(context.Background(), reader, &Config{Path: "/flags"})
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client, reader
+}
+
+func TestClientDefaults(t *testing.T) {
+	logtest.Replace(t)
+	// a missing document is not an error
+	client, _ := newTestClient(t, "")
+	ctx := WithUserID(context.Background(), "u1")
+	if !client.Bool(ctx, "checkout", true) || client.Bool(ctx, "checkout", false) {
+		t.Fatal("Bool() of a missing flag, want the default")
+	}
+	if got := client.String(ctx, "theme", "blue"); got != "blue" {
+		t.Fatalf("String() of a missing flag = %s, want blue", got)
+	}
+	if evaluation := client.Evaluate(ctx, "checkout"); evaluation.Reason != ReasonNotFound || evaluation.Value != nil {
+		t.Fatalf("Evaluate() of a missing flag = %+v, want not found", evaluation)
+	}
+}
+
+func TestClientEvaluate(t *testing.T) {
+	logs := logtest.Replace(t)
+	client, _ := newTestClient(t, testFlags)
+	ctx := WithUserID(context.Background(), "u1")
+
+	if !client.Bool(ctx, "checkout", false) {
+		t.Fatal("Bool(checkout) = false, want true")
+	}
+	if client.Bool(WithUserID(context.Background(), "u2"), "checkout", true) {
+		t.Fatal("Bool(checkout) of a denied user = true, want false")
+	}
+	if got := client.String(ctx, "theme", ""); got != "#000" {
+		t.Fatalf("String(theme) = %q, want #000", got)
+	}
+	// a value of another type returns the default
+	if got := client.String(ctx, "checkout", "none"); got != "none" {
+		t.Fatalf("String(checkout) = %q, want the default none", got)
+	}
+	if got := Value(ctx, client, "limits", limits{Max: 1}); got.Max != 100 {
+		t.Fatalf("Value(limits) = %+v, want max 100", got)
+	}
+	logs.AssertLogged(t, logger.InfoLevel, "feature flag evaluated")
+}
+
+func TestClientWatch(t *testing.T) {
+	logs := logtest.Replace(t)
+	client, reader := newTestClient(t, testFlags)
+	ctx := WithUserID(context.Background(), "u1")
+
+	// invalid definitions keep the previous flags
+	reader.change(t, []byte("checkout:\n    Enabled: true\n    OnVariant: missing\n"))
+	if !client.Bool(ctx, "checkout", false) || client.String(ctx, "theme", "") != "#000" {
+		t.Fatal("the flags changed after invalid definitions, want the previous flags")
+	}
+	logs.AssertLogged(t, logger.ErrorLevel, "unknown variant missing")
+
+	reader.change(t, []byte("checkout:\n    Enabled: false\n"))
+	if client.Bool(ctx, "checkout", true) || client.String(ctx, "theme", "blue") != "blue" {
+		t.Fatal("Bool(checkout) = true after the change, want the flag disabled and theme removed")
+	}
+
+	// a deleted document removes every flag
+	reader.change(t, nil)
+	if evaluation := client.Evaluate(ctx, "checkout"); evaluation.Reason != ReasonNotFound {
+		t.Fatalf("Evaluate() after the deletion = %+v, want not found", evaluation)
+	}
+}
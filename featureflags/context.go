@@ -0,0 +1,84 @@
+package featureflags
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/logger"
+	"google.golang.org/grpc/metadata"
+)
+
+type contextKey string
+
+const (
+	userIDContextKey      contextKey = "featureflags.userID"
+	evaluationsContextKey contextKey = "featureflags.evaluations"
+
+	// evaluationsKey is the log field of the flags evaluated for the request
+	evaluationsKey = "featureFlags"
+)
+
+// WithUserID returns a context which evaluates the flags for the given user id.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the user id the flags are evaluated for.
+// It is looked up in the order WithUserID, the constants.USER_ID grpc metadata and the logger.RequestContext.
+func UserIDFromContext(ctx context.Context) string {
+	if userID, ok := ctx.Value(userIDContextKey).(string); ok && userID != "" {
+		return userID
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(constants.USER_ID); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	if rc, ok := logger.RequestContextFromContext(ctx); ok {
+		return rc.UserID
+	}
+	return ""
+}
+
+// evaluations records the variants served during a request.
+type evaluations struct {
+	mu       sync.Mutex
+	variants map[string]string
+}
+
+// WithEvaluations returns a context which records the variant of every flag evaluated with it.
+// EvaluationsMiddleLayer adds the recorded variants to the log lines of the context, so that they can be audited with the request.
+func WithEvaluations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, evaluationsContextKey, &evaluations{variants: map[string]string{}})
+}
+
+func recordEvaluation(ctx context.Context, evaluation Evaluation) {
+	recorder, ok := ctx.Value(evaluationsContextKey).(*evaluations)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.variants[evaluation.Flag] = evaluation.Variant
+}
+
+// EvaluationsMiddleLayer is a logger.MiddleLayer which adds the variants recorded in the context by WithEvaluations as the featureFlags field.
+// e.g. logger.AddMiddleLayers(featureflags.EvaluationsMiddleLayer)
+func EvaluationsMiddleLayer(ctx context.Context, msg string, fields *logger.Fields) (context.Context, string, *logger.Fields) {
+	recorder, ok := ctx.Value(evaluationsContextKey).(*evaluations)
+	if !ok {
+		return ctx, msg, fields
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.variants) == 0 {
+		return ctx, msg, fields
+	}
+	variants := make(map[string]string, len(recorder.variants))
+	for flag, variant := range recorder.variants {
+		variants[flag] = variant
+	}
+	fields.AddField(evaluationsKey, variants)
+	return ctx, msg, fields
+}
@@ -0,0 +1,162 @@
+package featureflags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+
+	"github.com/gofreego/goutils/configutils"
+)
+
+const (
+	// OnVariant is the default variant served when the flag is on
+	OnVariant = "on"
+	// OffVariant is the default variant served when the flag is off
+	OffVariant = "off"
+
+	// rolloutBuckets is the number of buckets the users are hashed into, 0.01% granularity
+	rolloutBuckets = 10000
+)
+
+// Reason tells why a variant was served.
+type Reason string
+
+const (
+	ReasonNotFound Reason = "not_found"
+	ReasonDisabled Reason = "disabled"
+	ReasonDenied   Reason = "denied"
+	ReasonAllowed  Reason = "allowed"
+	ReasonEnabled  Reason = "enabled"
+	ReasonRollout  Reason = "rollout"
+	// ReasonNoUser is returned for rollouts when the context has no user id
+	ReasonNoUser Reason = "no_user"
+)
+
+// Flag represents the definition of a feature flag.
+// Enabled : disabled flags serve the OffVariant to everyone
+// Variants : values of the variants by name, default {"on": true, "off": false} for boolean flags
+// OnVariant : variant served to the allowed users, and to everyone when there is no rollout, default "on"
+// OffVariant : variant served when the flag is disabled, to the denied users and to the users outside the rollout, default "off"
+// Rollout : percentage of the users per variant, users are bucketed by the hash of the flag name and the user id
+// Allow : user ids which always get the OnVariant of an enabled flag
+// Deny : user ids which always get the OffVariant, deny wins over allow
+type Flag struct {
+	Enabled    bool           `yaml:"Enabled"`
+	Variants   map[string]any `yaml:"Variants"`
+	OnVariant  string         `yaml:"OnVariant" default:"on"`
+	OffVariant string         `yaml:"OffVariant" default:"off"`
+	Rollout    []Rollout      `yaml:"Rollout"`
+	Allow      []string       `yaml:"Allow"`
+	Deny       []string       `yaml:"Deny"`
+}
+
+// Rollout serves the variant to the given percentage of the users.
+type Rollout struct {
+	Variant    string  `yaml:"Variant"`
+	Percentage float64 `yaml:"Percentage" min:"0" max:"100"`
+}
+
+// Evaluation is the result of the evaluation of a flag for a user.
+type Evaluation struct {
+	Flag    string `json:"flag"`
+	Variant string `json:"variant"`
+	Value   any    `json:"value"`
+	Reason  Reason `json:"reason"`
+}
+
+// compiledFlag is a validated flag with the lookup sets, built once per reload.
+type compiledFlag struct {
+	*Flag
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// compile validates a copy of the definition, the definition of the caller is left unchanged.
+func compile(name string, definition *Flag) (*compiledFlag, error) {
+	flag := &Flag{
+		Enabled:    definition.Enabled,
+		Variants:   maps.Clone(definition.Variants),
+		OnVariant:  definition.OnVariant,
+		OffVariant: definition.OffVariant,
+		Rollout:    slices.Clone(definition.Rollout),
+		Allow:      slices.Clone(definition.Allow),
+		Deny:       slices.Clone(definition.Deny),
+	}
+	if err := configutils.ApplyDefaults(flag); err != nil {
+		return nil, err
+	}
+	if len(flag.Variants) == 0 {
+		flag.Variants = map[string]any{OnVariant: true, OffVariant: false}
+	}
+	variants := []string{flag.OffVariant}
+	// the OnVariant is served only to the allowed users and when there is no rollout
+	if len(flag.Rollout) == 0 || len(flag.Allow) > 0 {
+		variants = append(variants, flag.OnVariant)
+	}
+	total := 0.0
+	for _, rollout := range flag.Rollout {
+		if rollout.Percentage < 0 {
+			return nil, fmt.Errorf("flag %s has negative rollout percentage for variant %s", name, rollout.Variant)
+		}
+		total += rollout.Percentage
+		variants = append(variants, rollout.Variant)
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("flag %s has rollout percentages adding up to %v, must be <= 100", name, total)
+	}
+	for _, variant := range variants {
+		if _, ok := flag.Variants[variant]; !ok {
+			return nil, fmt.Errorf("flag %s has unknown variant %s", name, variant)
+		}
+	}
+
+	compiled := &compiledFlag{Flag: flag, allow: map[string]struct{}{}, deny: map[string]struct{}{}}
+	for _, userID := range flag.Allow {
+		compiled.allow[userID] = struct{}{}
+	}
+	for _, userID := range flag.Deny {
+		compiled.deny[userID] = struct{}{}
+	}
+	return compiled, nil
+}
+
+// evaluate returns the variant of the flag for the user and the reason.
+func (f *compiledFlag) evaluate(name string, userID string) (string, Reason) {
+	if !f.Enabled {
+		return f.OffVariant, ReasonDisabled
+	}
+	if userID != "" {
+		if _, ok := f.deny[userID]; ok {
+			return f.OffVariant, ReasonDenied
+		}
+		if _, ok := f.allow[userID]; ok {
+			return f.OnVariant, ReasonAllowed
+		}
+	}
+	if len(f.Rollout) == 0 {
+		return f.OnVariant, ReasonEnabled
+	}
+	if userID == "" {
+		return f.OffVariant, ReasonNoUser
+	}
+
+	point := bucket(name, userID)
+	cumulative := 0.0
+	for _, rollout := range f.Rollout {
+		cumulative += rollout.Percentage
+		if point < cumulative {
+			return rollout.Variant, ReasonRollout
+		}
+	}
+	return f.OffVariant, ReasonRollout
+}
+
+// bucket hashes the user into [0, 100), the flag name is part of the hash so that rollouts of different flags are independent.
+func bucket(name string, userID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{'/'})
+	h.Write([]byte(userID))
+	return float64(h.Sum32()%rolloutBuckets) * 100 / rolloutBuckets
+}
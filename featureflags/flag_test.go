@@ -0,0 +1,130 @@
+package featureflags
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustCompile(t *testing.T, flag *Flag) *compiledFlag {
+	t.Helper()
+	compiled, err := compile("checkout", flag)
+	if err != nil {
+		t.Fatalf("compile() = %v", err)
+	}
+	return compiled
+}
+
+func TestCompileCopiesDefinition(t *testing.T) {
+	definition := &Flag{Enabled: true, Allow: []string{"u1"}}
+	compiled := mustCompile(t, definition)
+
+	if !reflect.DeepEqual(definition, &Flag{Enabled: true, Allow: []string{"u1"}}) {
+		t.Fatalf("compile() changed the definition to %+v", definition)
+	}
+	if compiled.OnVariant != OnVariant || compiled.OffVariant != OffVariant || !reflect.DeepEqual(compiled.Variants, map[string]any{OnVariant: true, OffVariant: false}) {
+		t.Fatalf("compiled flag = %+v, want the default variants", compiled.Flag)
+	}
+	// later changes of the definition do not reach the compiled flag
+	definition.Allow[0] = "u2"
+	if variant, reason := compiled.evaluate("checkout", "u1"); variant != OnVariant || reason != ReasonAllowed {
+		t.Fatalf("evaluate(u1) = %s, %s, want on allowed", variant, reason)
+	}
+	if compiled.Allow[0] != "u1" {
+		t.Fatalf("compiled allow list = %v, want [u1]", compiled.Allow)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		flag *Flag
+		err  string
+	}{
+		{name: "negative", flag: &Flag{Rollout: []Rollout{{Variant: OnVariant, Percentage: -1}}}, err: "negative rollout percentage"},
+		{name: "over 100", flag: &Flag{Rollout: []Rollout{{Variant: OnVariant, Percentage: 60}, {Variant: OffVariant, Percentage: 50}}}, err: "adding up to 110"},
+		{name: "unknown rollout variant", flag: &Flag{Rollout: []Rollout{{Variant: "blue", Percentage: 10}}}, err: "unknown variant blue"},
+		{name: "unknown on variant", flag: &Flag{OnVariant: "green", Variants: map[string]any{"off": 1}}, err: "unknown variant green"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := compile("checkout", test.flag); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("compile() = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rollout := []Rollout{{Variant: OnVariant, Percentage: 0}}
+	tests := []struct {
+		name    string
+		flag    *Flag
+		userID  string
+		variant string
+		reason  Reason
+	}{
+		{name: "disabled", flag: &Flag{Allow: []string{"u1"}}, userID: "u1", variant: OffVariant, reason: ReasonDisabled},
+		{name: "enabled", flag: &Flag{Enabled: true}, userID: "u1", variant: OnVariant, reason: ReasonEnabled},
+		{name: "enabled without user", flag: &Flag{Enabled: true}, variant: OnVariant, reason: ReasonEnabled},
+		{name: "denied", flag: &Flag{Enabled: true, Deny: []string{"u1"}}, userID: "u1", variant: OffVariant, reason: ReasonDenied},
+		{name: "deny wins over allow", flag: &Flag{Enabled: true, Allow: []string{"u1"}, Deny: []string{"u1"}}, userID: "u1", variant: OffVariant, reason: ReasonDenied},
+		{name: "allowed outside the rollout", flag: &Flag{Enabled: true, Rollout: rollout, Allow: []string{"u1"}}, userID: "u1", variant: OnVariant, reason: ReasonAllowed},
+		{name: "outside the rollout", flag: &Flag{Enabled: true, Rollout: rollout, Allow: []string{"u1"}}, userID: "u2", variant: OffVariant, reason: ReasonRollout},
+		{name: "rollout without user", flag: &Flag{Enabled: true, Rollout: []Rollout{{Variant: OnVariant, Percentage: 100}}}, variant: OffVariant, reason: ReasonNoUser},
+		{name: "full rollout", flag: &Flag{Enabled: true, Rollout: []Rollout{{Variant: OnVariant, Percentage: 100}}}, userID: "u2", variant: OnVariant, reason: ReasonRollout},
+		{name: "custom variants", flag: &Flag{Enabled: true, Variants: map[string]any{"v2": 2, "v1": 1}, OnVariant: "v2", OffVariant: "v1"}, userID: "u1", variant: "v2", reason: ReasonEnabled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			variant, reason := mustCompile(t, test.flag).evaluate("checkout", test.userID)
+			if variant != test.variant || reason != test.reason {
+				t.Fatalf("evaluate(%q) = %s, %s, want %s, %s", test.userID, variant, reason, test.variant, test.reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateRolloutPercentage(t *testing.T) {
+	flag := mustCompile(t, &Flag{
+		Enabled:  true,
+		Variants: map[string]any{"control": 0, "a": 1, "b": 2},
+		// the users outside the rollouts get the OffVariant
+		OffVariant: "control",
+		Rollout:    []Rollout{{Variant: "a", Percentage: 20}, {Variant: "b", Percentage: 30}},
+	})
+
+	const users = 20000
+	counts := map[string]int{}
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		variant, reason := flag.evaluate("checkout", userID)
+		if reason != ReasonRollout {
+			t.Fatalf("evaluate(%s) reason = %s, want rollout", userID, reason)
+		}
+		// a user always gets the same variant
+		if again, _ := flag.evaluate("checkout", userID); again != variant {
+			t.Fatalf("evaluate(%s) = %s then %s, want a stable variant", userID, variant, again)
+		}
+		counts[variant]++
+	}
+	for variant, want := range map[string]float64{"a": 20, "b": 30, "control": 50} {
+		if got := float64(counts[variant]) * 100 / users; math.Abs(got-want) > 2 {
+			t.Fatalf("variant %s served to %.2f%% of the users, want %v%%", variant, got, want)
+		}
+	}
+
+	// the buckets of different flags are independent
+	same := 0
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if bucket("checkout", userID) == bucket("search", userID) {
+			same++
+		}
+	}
+	if same > 10 {
+		t.Fatalf("%d of 1000 users are in the same bucket for two flags", same)
+	}
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// asyncItem is a write performed by the background goroutine, or a flush marker if write is nil.
+type asyncItem struct {
+	write func() error
+	done  chan struct{}
+}
+
+// asyncWriter performs the writes of a sink in a background goroutine through a bounded buffer.
+// When the buffer is full the writes are dropped and counted, or block, as configured.
+type asyncWriter struct {
+	items   chan asyncItem
+	block   bool
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newAsyncWriter(config *AsyncSinkConfig) *asyncWriter {
+	size := config.BufferSize
+	if size <= 0 {
+		size = 1024
+	}
+	a := &asyncWriter{
+		items: make(chan asyncItem, size),
+		block: config.OnFull == "block",
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for item := range a.items {
+		if item.write != nil {
+			if err := item.write(); err != nil {
+				fmt.Fprintf(os.Stderr, "logger: async write failed: %v\n", err)
+			}
+		}
+		if item.done != nil {
+			close(item.done)
+		}
+	}
+}
+
+// submit queues the write, it is performed synchronously once the writer is closed.
+func (a *asyncWriter) submit(write func() error) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return write()
+	}
+	if a.block {
+		a.items <- asyncItem{write: write}
+		return nil
+	}
+	select {
+	case a.items <- asyncItem{write: write}:
+	default:
+		a.dropped.Add(1)
+	}
+	return nil
+}
+
+// flush waits until the queued writes are performed and reports the dropped writes.
+func (a *asyncWriter) flush() {
+	a.mu.RLock()
+	if !a.closed {
+		done := make(chan struct{})
+		a.items <- asyncItem{done: done}
+		a.mu.RUnlock()
+		<-done
+	} else {
+		a.mu.RUnlock()
+	}
+	if dropped := a.dropped.Swap(0); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "logger: dropped %d log entries, async buffer full\n", dropped)
+	}
+}
+
+// Close performs the queued writes and stops the background goroutine.
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.items)
+	a.mu.Unlock()
+	<-a.done
+	if dropped := a.dropped.Swap(0); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "logger: dropped %d log entries, async buffer full\n", dropped)
+	}
+	return nil
+}
+
+// asyncWriteSyncer writes to ws through the async writer.
+type asyncWriteSyncer struct {
+	async  *asyncWriter
+	ws     zapcore.WriteSyncer
+	closer io.Closer
+}
+
+func (s *asyncWriteSyncer) Write(p []byte) (int, error) {
+	// the encoder reuses p once Write returns
+	data := make([]byte, len(p))
+	copy(data, p)
+	err := s.async.submit(func() error {
+		_, err := s.ws.Write(data)
+		return err
+	})
+	return len(p), err
+}
+
+func (s *asyncWriteSyncer) Sync() error {
+	s.async.flush()
+	return s.ws.Sync()
+}
+
+func (s *asyncWriteSyncer) Close() error {
+	s.async.Close()
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
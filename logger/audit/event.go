@@ -0,0 +1,106 @@
+// Package audit records an append-only audit trail of who did what, separate from the application logs.
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofreego/goutils/logger"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is the schema of the audit events.
+// UserID and Permissions : the actor, from the x-user-id and x-permissions headers set by the gateway
+// Action : what was done, e.g. order.cancel, the middlewares use the request method
+// Resource : what it was done to, e.g. orders/42, the middlewares use the request path
+// Before and After : the state of the resource before and after the action, set by the handlers
+// Outcome : success or failure, the middlewares use the response status
+type Event struct {
+	ID          string         `json:"id"`
+	Time        time.Time      `json:"time"`
+	App         string         `json:"app"`
+	UserID      string         `json:"userId"`
+	Permissions []string       `json:"permissions,omitempty"`
+	Action      string         `json:"action"`
+	Resource    string         `json:"resource"`
+	Before      any            `json:"before,omitempty"`
+	After       any            `json:"after,omitempty"`
+	Outcome     string         `json:"outcome"`
+	Status      int            `json:"status,omitempty"`
+	RequestID   string         `json:"requestId,omitempty"`
+	Method      string         `json:"method,omitempty"`
+	URI         string         `json:"uri,omitempty"`
+	IP          string         `json:"ip,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+type actorKey struct{}
+type eventKey struct{}
+
+type actor struct {
+	userID      string
+	permissions []string
+}
+
+// WithActor returns a context carrying the user and the permissions of the events recorded with it.
+func WithActor(ctx context.Context, userID string, permissions []string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{userID: userID, permissions: permissions})
+}
+
+// parsePermissions splits the comma separated x-permissions header.
+func parsePermissions(header string) []string {
+	if header == "" {
+		return nil
+	}
+	var permissions []string
+	for _, permission := range strings.Split(header, ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// FromContext returns the event the middlewares record once the request is handled, nil outside of them.
+// The handlers describe the change through it, e.g. the resource, before and after.
+func FromContext(ctx context.Context) *Event {
+	event, _ := ctx.Value(eventKey{}).(*Event)
+	return event
+}
+
+// fill sets the fields of the event which are not set from the context.
+func (e *Event) fill(ctx context.Context, app string) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.App == "" {
+		e.App = app
+	}
+	if a, ok := ctx.Value(actorKey{}).(actor); ok {
+		if e.UserID == "" {
+			e.UserID = a.userID
+		}
+		if e.Permissions == nil {
+			e.Permissions = a.permissions
+		}
+	}
+	if rc, ok := logger.RequestContextFromContext(ctx); ok {
+		if e.UserID == "" {
+			e.UserID = rc.UserID
+		}
+		if e.RequestID == "" {
+			e.RequestID = rc.RequestID
+		}
+		if e.IP == "" {
+			e.IP = rc.IP
+		}
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+	}
+}
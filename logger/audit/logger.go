@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofreego/goutils/logger"
+	"github.com/google/uuid"
+)
+
+// Config : configuration of the audit logger
+// App : application name of the events
+// Methods : request methods recorded by the middlewares, default POST, PUT, PATCH, DELETE
+// File : file sink of the events, enabled when its path is set
+type Config struct {
+	App     string         `yaml:"App" json:"App" name:"App" type:"string" description:"Application Name" required:"true"`
+	Methods []string       `yaml:"Methods" json:"Methods" name:"Methods" description:"Request Methods Recorded by the Middlewares"`
+	File    FileSinkConfig `yaml:"File" json:"File" name:"File" description:"File Sink"`
+}
+
+// Logger records the audit events to its sinks.
+type Logger struct {
+	app     string
+	methods map[string]bool
+	sinks   []Sink
+}
+
+// NewLogger returns an audit logger writing to the file of the config and to the sinks, e.g. NewSQLSink or NewEventQueueSink.
+func NewLogger(config *Config, sinks ...Sink) (*Logger, error) {
+	if config.File.Path != "" {
+		file, err := NewFileSink(config.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, file)
+	}
+	if len(sinks) == 0 {
+		return nil, errors.New("audit logger requires a sink, set the file path or pass a sink")
+	}
+	methods := config.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	l := &Logger{app: config.App, methods: make(map[string]bool, len(methods)), sinks: sinks}
+	for _, method := range methods {
+		l.methods[strings.ToUpper(method)] = true
+	}
+	return l, nil
+}
+
+// Record writes the event to every sink, the id, time, app, actor and request fields are set from the context when empty.
+// The sinks which fail are logged and their errors returned, the event is still written to the others.
+func (l *Logger) Record(ctx context.Context, event *Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	event.fill(ctx, l.app)
+	var errs []error
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			logger.Error(ctx, "Error writing audit event %s : %v", event.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the sinks.
+func (l *Logger) Close() error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
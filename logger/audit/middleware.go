@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/logger"
+)
+
+// statusRecorder records the status written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(bytes []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(bytes)
+}
+
+// Unwrap lets http.ResponseController reach the flusher and hijacker of the wrapped writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// begin returns the pending event of the request from the client ip and the context carrying the event and the actor.
+func (l *Logger) begin(ctx context.Context, r *http.Request, ip string) (context.Context, *Event) {
+	event := &Event{
+		Action:   r.Method,
+		Resource: r.URL.Path,
+		Method:   r.Method,
+		URI:      r.RequestURI,
+		IP:       ip,
+	}
+	ctx = WithActor(ctx, r.Header.Get(constants.USER_ID), parsePermissions(r.Header.Get(constants.PERMISSIONS)))
+	return context.WithValue(ctx, eventKey{}, event), event
+}
+
+// clientIP returns the ip of the request context, the client ip behind the gateway, else the host of the remote address.
+func clientIP(ctx context.Context, r *http.Request) string {
+	if rc, ok := logger.RequestContextFromContext(ctx); ok && rc.IP != "" {
+		return rc.IP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// end records the event with the status of the response.
+func (l *Logger) end(ctx context.Context, event *Event, status int) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	event.Status = status
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+		if status >= http.StatusBadRequest {
+			event.Outcome = OutcomeFailure
+		}
+	}
+	l.Record(ctx, event)
+}
+
+// Middleware records an event for every request with one of the configured methods, once it is handled.
+// The handlers describe the change through FromContext, e.g. the resource, before and after.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.methods[r.Method] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, event := l.begin(r.Context(), r, clientIP(r.Context(), r))
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		l.end(ctx, event, recorder.status)
+	})
+}
+
+// GinMiddleware records an event for every request with one of the configured methods, once it is handled.
+// The handlers describe the change through FromContext, e.g. the resource, before and after.
+func (l *Logger) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.methods[c.Request.Method] {
+			c.Next()
+			return
+		}
+		ctx, event := l.begin(c.Request.Context(), c.Request, c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		l.end(ctx, event, c.Writer.Status())
+	}
+}
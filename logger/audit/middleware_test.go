@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/databases"
+	"github.com/gofreego/goutils/logger"
+)
+
+// memorySink keeps the events in memory.
+type memorySink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *memorySink) Write(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func newTestLogger(t *testing.T, config *Config) (*Logger, *memorySink) {
+	t.Helper()
+	sink := &memorySink{}
+	l, err := NewLogger(config, sink)
+	if err != nil {
+		t.Fatalf("NewLogger() = %v", err)
+	}
+	return l, sink
+}
+
+func serve(handler http.Handler, r *http.Request) {
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestMiddlewareMethods(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	l, sink := newTestLogger(t, &Config{App: "orders"})
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete} {
+		serve(l.Middleware(ok), httptest.NewRequest(method, "/orders", nil))
+	}
+	if len(sink.events) != 2 || sink.events[0].Method != http.MethodPost || sink.events[1].Method != http.MethodDelete {
+		t.Fatalf("events = %+v, want POST and DELETE", sink.events)
+	}
+
+	l, sink = newTestLogger(t, &Config{App: "orders", Methods: []string{"get"}})
+	serve(l.Middleware(ok), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	serve(l.Middleware(ok), httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if len(sink.events) != 1 || sink.events[0].Method != http.MethodGet {
+		t.Fatalf("events = %+v, want GET only", sink.events)
+	}
+}
+
+func TestMiddlewareOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		outcome string
+	}{
+		{name: "no write", handler: func(w http.ResponseWriter, r *http.Request) {}, status: http.StatusOK, outcome: OutcomeSuccess},
+		{name: "write", handler: func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }, status: http.StatusOK, outcome: OutcomeSuccess},
+		{name: "created", handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) }, status: http.StatusCreated, outcome: OutcomeSuccess},
+		{name: "client error", handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) }, status: http.StatusForbidden, outcome: OutcomeFailure},
+		{name: "handler outcome", handler: func(w http.ResponseWriter, r *http.Request) {
+			event := FromContext(r.Context())
+			event.Outcome, event.Action, event.Resource = OutcomeFailure, "order.cancel", "orders/42"
+		}, status: http.StatusOK, outcome: OutcomeFailure},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, sink := newTestLogger(t, &Config{App: "orders"})
+			serve(l.Middleware(test.handler), httptest.NewRequest(http.MethodPost, "/orders/42/cancel", nil))
+			if len(sink.events) != 1 {
+				t.Fatalf("events = %+v, want 1", sink.events)
+			}
+			event := sink.events[0]
+			if event.Status != test.status || event.Outcome != test.outcome || event.App != "orders" || event.ID == "" || event.Time.IsZero() {
+				t.Fatalf("event = %+v, want status %d and outcome %s", event, test.status, test.outcome)
+			}
+		})
+	}
+}
+
+func TestMiddlewareActor(t *testing.T) {
+	l, sink := newTestLogger(t, &Config{App: "orders"})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.RemoteAddr = "10.0.0.1:52000"
+	r.Header.Set(constants.USER_ID, "u1")
+	r.Header.Set(constants.PERMISSIONS, "orders.write, orders.read,,")
+	serve(handler, r)
+
+	// behind the gateway, the client ip is the one of the request context
+	r = httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r = r.WithContext(logger.WithRequestContext(r.Context(), logger.RequestContext{RequestID: "r1", UserID: "u2", IP: "203.0.113.7"}))
+	serve(handler, r)
+
+	if len(sink.events) != 2 {
+		t.Fatalf("events = %+v, want 2", sink.events)
+	}
+	first, second := sink.events[0], sink.events[1]
+	if first.UserID != "u1" || !reflect.DeepEqual(first.Permissions, []string{"orders.write", "orders.read"}) || first.IP != "10.0.0.1" {
+		t.Fatalf("event = %+v, want the actor of the headers", first)
+	}
+	if second.UserID != "u2" || second.RequestID != "r1" || second.IP != "203.0.113.7" {
+		t.Fatalf("event = %+v, want the request context", second)
+	}
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l, sink := newTestLogger(t, &Config{App: "orders"})
+	router := gin.New()
+	router.Use(l.GinMiddleware())
+	router.DELETE("/orders/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	r := httptest.NewRequest(http.MethodDelete, "/orders/42", nil)
+	r.RemoteAddr = "10.0.0.1:52000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	serve(router, r)
+	serve(router, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+	if len(sink.events) != 1 {
+		t.Fatalf("events = %+v, want the DELETE only", sink.events)
+	}
+	event := sink.events[0]
+	if event.Status != http.StatusNotFound || event.Outcome != OutcomeFailure || event.Resource != "/orders/42" || event.IP != "203.0.113.7" {
+		t.Fatalf("event = %+v", event)
+	}
+}
+
+func TestSQLSinkDialects(t *testing.T) {
+	columns := strings.Join(sqlColumns, ", ")
+	tests := []struct {
+		dialect      databases.DatabaseName
+		table        string
+		placeholders string
+	}{
+		{dialect: databases.Postgres, table: "audit_logs", placeholders: "$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16"},
+		{dialect: databases.MySQL, table: "audit", placeholders: strings.Repeat("?, ", len(sqlColumns)-1) + "?"},
+		{dialect: databases.ClickHouse, table: "audit", placeholders: strings.Repeat("?, ", len(sqlColumns)-1) + "?"},
+	}
+	for _, test := range tests {
+		table := test.table
+		if table == "audit_logs" {
+			table = ""
+		}
+		sink, err := NewSQLSink(nil, SQLSinkConfig{Table: table, Dialect: test.dialect})
+		if err != nil {
+			t.Fatalf("NewSQLSink(%s) = %v", test.dialect, err)
+		}
+		want := "INSERT INTO " + test.table + " (" + columns + ") VALUES (" + test.placeholders + ")"
+		if got := sink.(*sqlSink).insert; got != want {
+			t.Fatalf("insert of %s = %s, want %s", test.dialect, got, want)
+		}
+	}
+	if _, err := NewSQLSink(nil, SQLSinkConfig{Dialect: "Oracle"}); err == nil {
+		t.Fatal("NewSQLSink() of an unsupported dialect = nil, want an error")
+	}
+}
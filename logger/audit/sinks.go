@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gofreego/goutils/databases"
+	"github.com/gofreego/goutils/eventqueue"
+	"github.com/gofreego/goutils/eventqueue/models"
+)
+
+// Sink stores the audit events, the events are only appended.
+type Sink interface {
+	Write(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// FileSinkConfig : json lines file of the events, the file is opened in append mode and never rotated
+// Path : path of the file, its directory is created
+// Sync : syncs the file to the disk after every event
+type FileSinkConfig struct {
+	Path string `yaml:"Path" json:"Path" name:"Path" type:"string" description:"Path of the Audit File"`
+	Sync bool   `yaml:"Sync" json:"Sync" name:"Sync" type:"bool" description:"Sync the File after Every Event"`
+}
+
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	sync bool
+}
+
+// NewFileSink returns a sink appending the events as json lines to the file.
+func NewFileSink(config FileSinkConfig) (Sink, error) {
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file, sync: config.Sync}, nil
+}
+
+func (s *fileSink) Write(ctx context.Context, event *Event) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	bytes = append(bytes, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(bytes); err != nil {
+		return err
+	}
+	if s.sync {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SQLSinkConfig : table of the events
+// Table : name of the table, default audit_logs
+// Dialect : Postgres, MySQL or ClickHouse, selects the placeholders of the insert
+type SQLSinkConfig struct {
+	Table   string                 `yaml:"Table" json:"Table" name:"Table" type:"string" description:"Audit Table" default:"audit_logs"`
+	Dialect databases.DatabaseName `yaml:"Dialect" json:"Dialect" name:"Dialect" type:"choice" description:"Database Type" choices:"Postgres,MySQL,ClickHouse"`
+}
+
+var sqlColumns = []string{"id", "time", "app", "user_id", "permissions", "action", "resource", "before", "after", "outcome", "status", "request_id", "method", "uri", "ip", "metadata"}
+
+type sqlSink struct {
+	db     *sql.DB
+	insert string
+}
+
+// NewSQLSink returns a sink inserting the events in the table, e.g. the primary of a databases/connections/sql DBManager.
+// The permissions, before, after and metadata columns hold json, the table is created by the migrations of the service.
+func NewSQLSink(db *sql.DB, config SQLSinkConfig) (Sink, error) {
+	if config.Table == "" {
+		config.Table = "audit_logs"
+	}
+	placeholders := make([]string, len(sqlColumns))
+	for i := range sqlColumns {
+		switch config.Dialect {
+		case databases.Postgres:
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		case databases.MySQL, databases.ClickHouse:
+			placeholders[i] = "?"
+		default:
+			return nil, fmt.Errorf("unsupported database type: %s, expected: %v", config.Dialect, []databases.DatabaseName{databases.Postgres, databases.MySQL, databases.ClickHouse})
+		}
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", config.Table, strings.Join(sqlColumns, ", "), strings.Join(placeholders, ", "))
+	return &sqlSink{db: db, insert: insert}, nil
+}
+
+func (s *sqlSink) Write(ctx context.Context, event *Event) error {
+	permissions, err := jsonColumn(event.Permissions)
+	if err != nil {
+		return err
+	}
+	before, err := jsonColumn(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := jsonColumn(event.After)
+	if err != nil {
+		return err
+	}
+	metadata, err := jsonColumn(event.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.insert, event.ID, event.Time, event.App, event.UserID, permissions, event.Action, event.Resource,
+		before, after, event.Outcome, event.Status, event.RequestID, event.Method, event.URI, event.IP, metadata)
+	return err
+}
+
+// jsonColumn returns the json of the value, nil for the empty values.
+func jsonColumn(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(bytes) == "null" {
+		return nil, nil
+	}
+	return string(bytes), nil
+}
+
+// Close does not close the database, it is owned by the caller.
+func (s *sqlSink) Close() error {
+	return nil
+}
+
+// message is the event queue message of an event, its key is the resource so that the events of a resource stay ordered.
+type message struct {
+	key   string
+	value []byte
+}
+
+func (m *message) GetKey() any {
+	return m.key
+}
+
+func (m *message) GetValue() any {
+	return m.value
+}
+
+type eventQueueSink struct {
+	queue eventqueue.EventQueue
+}
+
+// NewEventQueueSink returns a sink publishing the events as json to the queue, its topic is configured on the queue.
+func NewEventQueueSink(queue eventqueue.EventQueue) Sink {
+	return &eventQueueSink{queue: queue}
+}
+
+func (s *eventQueueSink) Write(ctx context.Context, event *Event) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.queue.Publish(ctx, models.IMessage(&message{key: event.Resource, value: bytes}))
+}
+
+// Close does not close the queue, it is owned by the caller.
+func (s *eventQueueSink) Close() error {
+	return nil
+}
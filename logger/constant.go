@@ -10,6 +10,9 @@ const (
 	uriKey       = "uri"
 	ipKey        = "ip"
 	methodKey    = "method"
+	traceIDKey   = "traceId"
+	spanIDKey    = "spanId"
+	errorKey     = "error"
 )
 
 type Build string
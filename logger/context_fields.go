@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextFieldsKey struct{}
+
+// WithContextFields returns a context carrying the fields, every entry logged with the context has them.
+// The fields of ctx are kept, the returned context has its own copy so ctx and its other children are not modified.
+func WithContextFields(ctx context.Context, fields ...*Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing := ContextFields(ctx)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	for _, field := range fields {
+		merged = append(merged, zap.Any(field.Key, field.Value))
+	}
+	return context.WithValue(ctx, contextFieldsKey{}, merged)
+}
+
+// ContextFields returns the fields added to ctx by WithContextFields, the returned slice must not be modified.
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextFieldsKey{}).([]zap.Field)
+	return fields
+}
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Encoding string
+
+const (
+	EncodingConsole Encoding = "console"
+	EncodingJSON    Encoding = "json"
+	EncodingLogfmt  Encoding = "logfmt"
+)
+
+// Schema is the field naming of a log platform.
+type Schema string
+
+const (
+	// SchemaECS : Elastic Common Schema, @timestamp, log.level, message, log.origin, service.name
+	SchemaECS Schema = "ecs"
+	// SchemaGCP : Google Cloud Logging, timestamp, severity, message, logging.googleapis.com/sourceLocation, serviceContext
+	SchemaGCP Schema = "gcp"
+	// SchemaDatadog : Datadog standard attributes, timestamp, status, message, service
+	SchemaDatadog Schema = "datadog"
+
+	ecsVersion = "8.11.0"
+)
+
+func init() {
+	if err := zap.RegisterEncoder(string(EncodingLogfmt), func(config zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return newLogfmtEncoder(config), nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// schema is the encoder config of a schema with the fields it adds to every entry.
+type schema struct {
+	encoderConfig zapcore.EncoderConfig
+	// appNameField returns the field of the app name
+	appNameField func(appName string) zap.Field
+	// callerField returns the caller as an object field, for the schemas whose caller is not a string
+	callerField func(caller zapcore.EntryCaller) zap.Field
+	fields      []zap.Field
+}
+
+// configEncoding returns the encoding of the config, the default is json for prod builds and the schemas, and console for dev builds.
+func configEncoding(config *Config) (Encoding, error) {
+	switch config.Encoding {
+	case EncodingConsole, EncodingJSON, EncodingLogfmt:
+		return config.Encoding, nil
+	case "":
+		if config.Build == BuildProd || config.Schema != "" {
+			return EncodingJSON, nil
+		}
+		return EncodingConsole, nil
+	}
+	return "", errors.New("invalid encoding in config")
+}
+
+func newEncoder(encoding Encoding, encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	switch encoding {
+	case EncodingJSON:
+		return zapcore.NewJSONEncoder(encoderConfig)
+	case EncodingLogfmt:
+		return newLogfmtEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+// configSchema returns the schema of the config, the default schema has the timeStamp, level, msg and caller keys and the App field.
+func configSchema(config *Config) (*schema, error) {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = timeKey
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	s := &schema{
+		encoderConfig: encoderConfig,
+		appNameField: func(appName string) zap.Field {
+			return zap.String("App", appName)
+		},
+	}
+	switch config.Schema {
+	case "":
+	case SchemaECS:
+		s.encoderConfig.TimeKey = "@timestamp"
+		s.encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02T15:04:05.000Z07:00")
+		s.encoderConfig.LevelKey = "log.level"
+		s.encoderConfig.MessageKey = "message"
+		s.encoderConfig.NameKey = "log.logger"
+		s.encoderConfig.StacktraceKey = "error.stack_trace"
+		s.encoderConfig.CallerKey = zapcore.OmitKey
+		s.callerField = func(caller zapcore.EntryCaller) zap.Field {
+			return zap.Dict("log.origin", zap.String("file.name", caller.File), zap.Int("file.line", caller.Line), zap.String("function", caller.Function))
+		}
+		s.appNameField = func(appName string) zap.Field {
+			return zap.String("service.name", appName)
+		}
+		s.fields = []zap.Field{zap.String("ecs.version", ecsVersion)}
+	case SchemaGCP:
+		s.encoderConfig.TimeKey = "timestamp"
+		s.encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		s.encoderConfig.LevelKey = "severity"
+		s.encoderConfig.EncodeLevel = levelEncoder(gcpSeverities)
+		s.encoderConfig.MessageKey = "message"
+		s.encoderConfig.NameKey = "logger"
+		s.encoderConfig.StacktraceKey = "stack_trace"
+		s.encoderConfig.CallerKey = zapcore.OmitKey
+		s.callerField = func(caller zapcore.EntryCaller) zap.Field {
+			return zap.Dict("logging.googleapis.com/sourceLocation", zap.String("file", caller.File), zap.Int("line", caller.Line), zap.String("function", caller.Function))
+		}
+		s.appNameField = func(appName string) zap.Field {
+			return zap.Dict("serviceContext", zap.String("service", appName))
+		}
+	case SchemaDatadog:
+		s.encoderConfig.TimeKey = "timestamp"
+		s.encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02T15:04:05.000Z07:00")
+		s.encoderConfig.LevelKey = "status"
+		s.encoderConfig.EncodeLevel = levelEncoder(datadogStatuses)
+		s.encoderConfig.MessageKey = "message"
+		s.encoderConfig.NameKey = "logger.name"
+		s.encoderConfig.StacktraceKey = "error.stack"
+		s.appNameField = func(appName string) zap.Field {
+			return zap.String("service", appName)
+		}
+	default:
+		return nil, errors.New("invalid schema in config")
+	}
+	return s, nil
+}
+
+var gcpSeverities = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "DEBUG",
+	zapcore.InfoLevel:   "INFO",
+	zapcore.WarnLevel:   "WARNING",
+	zapcore.ErrorLevel:  "ERROR",
+	zapcore.DPanicLevel: "CRITICAL",
+	zapcore.PanicLevel:  "ALERT",
+	zapcore.FatalLevel:  "EMERGENCY",
+}
+
+var datadogStatuses = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "debug",
+	zapcore.InfoLevel:   "info",
+	zapcore.WarnLevel:   "warn",
+	zapcore.ErrorLevel:  "error",
+	zapcore.DPanicLevel: "critical",
+	zapcore.PanicLevel:  "critical",
+	zapcore.FatalLevel:  "emergency",
+}
+
+// levelEncoder encodes the levels with the severity names of a schema.
+func levelEncoder(severities map[zapcore.Level]string) zapcore.LevelEncoder {
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if severity, ok := severities[level]; ok {
+			enc.AppendString(severity)
+			return
+		}
+		enc.AppendString(level.String())
+	}
+}
+
+// wrap adds the fields and the caller field of the schema to the entries of core.
+func (s *schema) wrap(core zapcore.Core) zapcore.Core {
+	if len(s.fields) > 0 {
+		core = core.With(s.fields)
+	}
+	if s.callerField != nil {
+		core = &callerCore{Core: core, callerField: s.callerField}
+	}
+	return core
+}
+
+// callerCore writes the caller of the entries as an object field.
+type callerCore struct {
+	zapcore.Core
+	callerField func(caller zapcore.EntryCaller) zap.Field
+}
+
+func (c *callerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerCore{Core: c.Core.With(fields), callerField: c.callerField}
+}
+
+func (c *callerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// Write checks the entry against the wrapped core, so that its sampling and the levels of its sinks apply.
+func (c *callerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Caller.Defined {
+		fields = append(fields[:len(fields):len(fields)], c.callerField(entry.Caller))
+	}
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// encodePrimitive returns the value appended by encode, e.g. to use the time and level encoders of an encoder config.
+func encodePrimitive(encode func(enc zapcore.PrimitiveArrayEncoder)) any {
+	m := zapcore.NewMapObjectEncoder()
+	m.AddArray("value", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		encode(enc)
+		return nil
+	}))
+	if values, ok := m.Fields["value"].([]any); ok && len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
+
+// encodeTime returns the time encoded by the encoder config, RFC3339 without a time encoder.
+func encodeTime(config zapcore.EncoderConfig, t time.Time) any {
+	if config.EncodeTime == nil {
+		return t.Format(time.RFC3339Nano)
+	}
+	return encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		config.EncodeTime(t, enc)
+	})
+}
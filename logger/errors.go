@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/gofreego/goutils/customerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stackTracer is implemented by the errors capturing the stack trace where they were created, e.g. customerrors.Wrap.
+type stackTracer interface {
+	StackTrace() []uintptr
+}
+
+// errorObject writes an error with its chain, customerrors code and stack trace.
+type errorObject struct {
+	err error
+	// stack is unset below the stack trace level of the logger
+	stack bool
+	// redact masks the messages when the redaction is enabled
+	redact func(string) string
+}
+
+// Err returns the error field, it writes the message, type, chain of the wrapped errors,
+// code and http status of a customerrors.Error in the chain and the stack trace captured by customerrors.Wrap.
+// The stack trace is written for the entries at or above the stack trace level of the config.
+//
+//	logger.Errorf(ctx, "Error creating order", logger.NewFields().Add(logger.Err(err)))
+func Err(err error) *Field {
+	if err == nil {
+		return &Field{Key: errorKey, Value: nil}
+	}
+	return &Field{Key: errorKey, Value: errorObject{err: err, stack: true}}
+}
+
+// Add adds the fields, e.g. logger.Err(err).
+func (f *Fields) Add(fields ...*Field) *Fields {
+	for _, field := range fields {
+		f.fields = append(f.fields, zap.Any(field.Key, field.Value))
+	}
+	return f
+}
+
+func (e errorObject) message(msg string) string {
+	if e.redact != nil {
+		return e.redact(msg)
+	}
+	return msg
+}
+
+func (e errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.message(e.err.Error()))
+	enc.AddString("type", fmt.Sprintf("%T", e.err))
+	var customErr *customerrors.Error
+	if errors.As(e.err, &customErr) {
+		enc.AddInt("code", customErr.Code())
+		enc.AddInt("httpStatus", customErr.HTTPStatus())
+	}
+	if chain := errorChain(e.err); len(chain) > 1 {
+		enc.AddArray("chain", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, err := range chain {
+				arr.AppendString(e.message(err.Error()))
+			}
+			return nil
+		}))
+	}
+	if e.stack {
+		if stack := errorStack(e.err); stack != "" {
+			enc.AddString("stacktrace", stack)
+		}
+	}
+	return nil
+}
+
+// errorChain returns err and the errors it wraps, depth first for the errors joining several errors.
+func errorChain(err error) []error {
+	var chain []error
+	for err != nil {
+		chain = append(chain, err)
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, joined := range wrapped.Unwrap() {
+				chain = append(chain, errorChain(joined)...)
+			}
+			return chain
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		default:
+			return chain
+		}
+	}
+	return chain
+}
+
+// errorStack returns the stack trace of the innermost error of the chain which captured one, the closest to the origin of the error.
+func errorStack(err error) string {
+	var pcs []uintptr
+	for _, err := range errorChain(err) {
+		if tracer, ok := err.(stackTracer); ok && len(tracer.StackTrace()) > 0 {
+			pcs = tracer.StackTrace()
+		}
+	}
+	if len(pcs) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if builder.Len() > 0 {
+			builder.WriteByte('\n')
+		}
+		fmt.Fprintf(&builder, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return builder.String()
+}
+
+// withoutErrorStacks returns the fields with the stack traces of the error fields removed, the fields are copied only when needed.
+func withoutErrorStacks(fields []zapcore.Field) []zapcore.Field {
+	copied := false
+	for i, field := range fields {
+		obj, ok := field.Interface.(errorObject)
+		if !ok || !obj.stack {
+			continue
+		}
+		if !copied {
+			fields = append([]zapcore.Field(nil), fields...)
+			copied = true
+		}
+		obj.stack = false
+		fields[i] = zap.Object(field.Key, obj)
+	}
+	return fields
+}
@@ -4,51 +4,207 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type logger struct {
-	zapLogger    *zap.Logger
-	appNameField zap.Field
+	*loggerBase
+	// fields of the child logger created by With, added to every entry
+	fields []zap.Field
+	// global is set for the global logger, it is called through the package functions
+	global bool
+}
+
+// loggerBase is shared by a logger and its child loggers.
+type loggerBase struct {
+	state        atomic.Pointer[loggerState]
+	levels       *levels
 	middleLayers []MiddleLayer
+	// core replaces the configured sinks, see NewCoreLogger
+	core zapcore.Core
+}
+
+// loggerState is the part of the logger rebuilt by ChangeConfig, it is swapped atomically while other goroutines are logging.
+type loggerState struct {
+	zapLogger *zap.Logger
+	// globalLogger skips the package functions of the global logger to report their caller
+	globalLogger *zap.Logger
+	appNameField zap.Field
+	limiter      *rateLimiter
+	suppressed   *suppressed
+	redactor     *redactor
+	// closers release the sinks of the zap logger
+	closers []io.Closer
+}
+
+// allow reports whether the entry of the level and message template passes the rate limit, suppressed entries are counted.
+func (s *loggerState) allow(level zapcore.Level, template string) bool {
+	if s.limiter.allow(level, template) {
+		return true
+	}
+	s.suppressed.add(level, template)
+	return false
+}
+
+func (s *loggerState) close() {
+	for _, closer := range s.closers {
+		closer.Close()
+	}
 }
 
 func NewLogger(config *Config, middleLayers ...MiddleLayer) (Logger, error) {
-	var err error
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.TimeKey = timeKey
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	l, err := newLogger(config, nil, middleLayers)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// NewCoreLogger returns a logger writing to core instead of the sinks of the config, e.g. the observer core of zaptest to capture the entries.
+// The levels, sampling, rate limit, redaction and middle-layers of the config apply as for NewLogger, also after ChangeConfig.
+func NewCoreLogger(config *Config, core zapcore.Core, middleLayers ...MiddleLayer) (Logger, error) {
+	l, err := newLogger(config, core, middleLayers)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func newLogger(config *Config, core zapcore.Core, middleLayers []MiddleLayer) (*logger, error) {
+	level, err := configLevel(config)
+	if err != nil {
+		return nil, err
+	}
+	l := &logger{loggerBase: &loggerBase{
+		levels:       newLevels(level),
+		middleLayers: middleLayers,
+		core:         core,
+	}, global: config.global}
+	state, err := l.build(config)
+	if err != nil {
+		return nil, err
+	}
+	l.state.Store(state)
+	return l, nil
+}
+
+// configLevel returns the level of the config, the default is debug for dev and info for prod builds.
+func configLevel(config *Config) (zapcore.Level, error) {
+	if config.Level == "" {
+		if config.Build == BuildProd {
+			return zapcore.InfoLevel, nil
+		}
+		return zapcore.DebugLevel, nil
+	}
+	level, found := logLevelToZapLevelMap[config.Level]
+	if !found {
+		return level, errors.New("invalid log level in config")
+	}
+	return level, nil
+}
+
+// stacktraceLevel returns the minimum level of the stack traces, default error, none is above every level.
+func stacktraceLevel(config *Config) (zapcore.Level, error) {
+	switch config.StacktraceLevel {
+	case "":
+		return zapcore.ErrorLevel, nil
+	case "none":
+		return zapcore.InvalidLevel, nil
+	}
+	level, found := logLevelToZapLevelMap[config.StacktraceLevel]
+	if !found {
+		return level, errors.New("invalid stack trace level in config")
+	}
+	return level, nil
+}
+
+// build builds the zap logger of the config, its level is the shared level of the logger.
+func (l *logger) build(config *Config) (*loggerState, error) {
+	schema, err := configSchema(config)
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := configEncoding(config)
+	if err != nil {
+		return nil, err
+	}
 	var zapConfig zap.Config
 	if config.Build == "prod" {
 		zapConfig = zap.NewProductionConfig()
 	} else {
 		zapConfig = zap.NewDevelopmentConfig()
 	}
-	if config.Level != "" {
-		level, found := logLevelToZapLevelMap[config.Level]
-		if !found {
-			return nil, errors.New("invalid log level in config")
-		}
-		zapConfig.Level = zap.NewAtomicLevelAt(level)
-	}
+	// the levels are enforced by the levelCore wrapping the zap core
+	zapConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	// the stack traces are added by AddStacktrace with the configured level instead of the default level of the build
 	zapConfig.DisableStacktrace = true
+	stackLevel, err := stacktraceLevel(config)
+	if err != nil {
+		return nil, err
+	}
+	// the sampling is applied on the sinks below
+	zapConfig.Sampling = nil
+	sampling := zapSampling(config)
+	limiter := newRateLimiter(config.RateLimit)
+	var sup *suppressed
+	if sampling.enabled() || limiter != nil {
+		sup = newSuppressed()
+	}
 
-	zapConfig.EncoderConfig = encoderConfig
-	appNameField := zap.Field{Key: "App", Type: zapcore.StringType, String: config.AppName}
-	if config.skipLevels <= 0 {
-		config.skipLevels = 1
+	zapConfig.Encoding = string(encoding)
+	zapConfig.EncoderConfig = schema.encoderConfig
+	appNameField := schema.appNameField(config.AppName)
+
+	var sinks []zapcore.Core
+	var closers []io.Closer
+	if len(config.Sinks) > 0 && l.core == nil {
+		var err error
+		sinks, closers, err = buildSinks(config.Sinks, newEncoder(encoding, schema.encoderConfig), schema.encoderConfig, config.AppName)
+		if err != nil {
+			return nil, err
+		}
 	}
-	zapLogger, err := zapConfig.Build(zap.AddStacktrace(zapcore.ErrorLevel), zap.AddCallerSkip(config.skipLevels))
+	zapLogger, err := zapConfig.Build(
+		zap.AddStacktrace(stackLevel),
+		zap.AddCallerSkip(1),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			// the configured sinks replace the default stderr output
+			if l.core != nil {
+				core = l.core
+			} else if len(sinks) > 0 {
+				core = zapcore.NewTee(sinks...)
+			}
+			core = schema.wrap(core)
+			if sampling.enabled() {
+				core = zapcore.NewSamplerWithOptions(core, sampling.tick(), sampling.Initial, sampling.Thereafter,
+					zapcore.SamplerHook(func(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+						if decision&zapcore.LogDropped != 0 {
+							sup.add(entry.Level, entry.Message)
+						}
+					}))
+			}
+			return &levelCore{Core: core, levels: l.levels, stackLevel: stackLevel}
+		}),
+	)
 	if err != nil {
+		(&loggerState{closers: closers}).close()
 		return nil, err
 	}
-	return &logger{
-		zapLogger:    zapLogger,
-		appNameField: appNameField,
-		middleLayers: middleLayers,
-	}, nil
+	if sup != nil {
+		interval := config.SuppressedSummaryInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		go sup.run(zapLogger, interval)
+		// the last summary is logged before the sinks are closed
+		closers = append([]io.Closer{sup}, closers...)
+	}
+	return &loggerState{zapLogger: zapLogger, globalLogger: zapLogger.WithOptions(zap.AddCallerSkip(1)), appNameField: appNameField, limiter: limiter, suppressed: sup, redactor: newRedactor(config.Redaction), closers: closers}, nil
 }
 
 func (l *logger) AddMiddleLayers(middlelayers ...MiddleLayer) {
@@ -59,103 +215,202 @@ func (l *logger) ReplaceMiddleLayers(middlelayers ...MiddleLayer) {
 	l.middleLayers = middlelayers
 }
 
+// ChangeConfig changes the level through the shared atomic level and swaps the rebuilt zap logger atomically, it is safe while logging.
+// The per-package level overrides are kept.
 func (l *logger) ChangeConfig(config *Config) error {
-	var err error
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.TimeKey = timeKey
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	var zapConfig zap.Config
-	if config.Build == "prod" {
-		zapConfig = zap.NewProductionConfig()
-	} else {
-		zapConfig = zap.NewDevelopmentConfig()
+	level, err := configLevel(config)
+	if err != nil {
+		return err
 	}
-	if config.Level != "" {
-		level, found := logLevelToZapLevelMap[config.Level]
-		if !found {
-			return errors.New("invalid log level in config")
-		}
-		zapConfig.Level = zap.NewAtomicLevelAt(level)
+	state, err := l.build(config)
+	if err != nil {
+		return err
 	}
-	zapConfig.DisableStacktrace = true
+	old := l.state.Swap(state)
+	l.levels.level.SetLevel(level)
+	// entries logged through the old state after the swap are written synchronously or reopen the file
+	old.zapLogger.Sync()
+	old.close()
+	return nil
+}
+
+// Sync flushes the buffered entries of the sinks, call it before the application exits.
+func (l *logger) Sync() error {
+	return l.state.Load().zapLogger.Sync()
+}
+
+// Levels returns the runtime level configuration of the logger.
+func (l *logger) Levels() LevelConfig {
+	return l.levels.config()
+}
+
+// SetLevels changes the level and the per-package overrides of the logger at runtime.
+// With a ttl > 0 the levels revert to the configuration before the change after ttl.
+func (l *logger) SetLevels(config LevelConfig, ttl time.Duration) error {
+	return l.levels.set(config, ttl)
+}
 
-	zapConfig.EncoderConfig = encoderConfig
-	l.appNameField.String = config.AppName
-	if config.skipLevels <= 0 {
-		config.skipLevels = 1
+// With returns a child logger adding the fields to every entry, it shares the configuration, levels and middle-layers of l.
+func (l *logger) With(fields ...*Field) Logger {
+	child := &logger{loggerBase: l.loggerBase}
+	child.fields = make([]zap.Field, 0, len(l.fields)+len(fields))
+	child.fields = append(child.fields, l.fields...)
+	for _, field := range fields {
+		child.fields = append(child.fields, zap.Any(field.Key, field.Value))
 	}
-	l.zapLogger, err = zapConfig.Build(zap.AddStacktrace(zapcore.ErrorLevel), zap.AddCallerSkip(config.skipLevels))
-	return err
+	return child
+}
+
+func (l *logger) zapLogger(state *loggerState) *zap.Logger {
+	if l.global {
+		return state.globalLogger
+	}
+	return state.zapLogger
+}
+
+// entryFields returns new fields of an entry, the fields of the logger, of the context, the given fields and the app name.
+// The given fields are copied, so the middle-layers never modify the fields of the caller.
+func (l *logger) entryFields(ctx context.Context, state *loggerState, fs *Fields) *Fields {
+	contextFields := ContextFields(ctx)
+	size := len(l.fields) + len(contextFields) + 1
+	if fs != nil {
+		size += len(fs.fields)
+	}
+	fields := make([]zap.Field, 0, size)
+	fields = append(fields, l.fields...)
+	fields = append(fields, contextFields...)
+	if fs != nil {
+		fields = append(fields, fs.fields...)
+	}
+	fields = append(fields, state.appNameField)
+	return &Fields{fields: fields}
 }
 
 func (l *logger) executeMiddleLayers(ctx context.Context, msg string, fields *Fields) (context.Context, string, *Fields) {
 	for _, layer := range l.middleLayers {
 		ctx, msg, fields = layer(ctx, msg, fields)
 	}
+	// redaction runs last so that the fields added by every middle-layer are masked too
+	msg, fields = l.state.Load().redactor.redact(msg, fields)
 	return ctx, msg, fields
 }
 
 func (l *logger) Info(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Info(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.InfoLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.InfoLevel, format) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Info(msg, fields.fields...)
 }
 
 func (l *logger) Debug(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Debug(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.DebugLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.DebugLevel, format) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Debug(msg, fields.fields...)
 }
 
 func (l *logger) Error(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Error(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.ErrorLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.ErrorLevel, format) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Error(msg, fields.fields...)
 }
 
 func (l *logger) Warn(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Warn(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.WarnLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.WarnLevel, format) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Warn(msg, fields.fields...)
 }
 
 func (l *logger) Panic(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Panic(msg, fields.fields...)
+	state := l.state.Load()
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Panic(msg, fields.fields...)
 }
 
 func (l *logger) Fatal(ctx context.Context, format string, a ...any) {
-	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), &Fields{fields: []zap.Field{l.appNameField}})
-	l.zapLogger.Fatal(msg, fields.fields...)
+	state := l.state.Load()
+	_, msg, fields := l.executeMiddleLayers(ctx, fmt.Sprintf(format, a...), l.entryFields(ctx, state, nil))
+	l.zapLogger(state).Fatal(msg, fields.fields...)
 }
 
 func (l *logger) Infof(ctx context.Context, msg string, fs *Fields) {
-	fs.fields = append(fs.fields, l.appNameField)
-	_, msg, fields := l.executeMiddleLayers(ctx, msg, fs)
-	l.zapLogger.Info(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.InfoLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.InfoLevel, msg) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fs))
+	l.zapLogger(state).Info(msg, fields.fields...)
 }
 
 func (l *logger) Debugf(ctx context.Context, msg string, fields *Fields) {
-	fields.fields = append(fields.fields, l.appNameField)
-	_, msg, fields = l.executeMiddleLayers(ctx, msg, fields)
-	l.zapLogger.Debug(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.DebugLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.DebugLevel, msg) {
+		return
+	}
+	_, msg, fields = l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fields))
+	l.zapLogger(state).Debug(msg, fields.fields...)
 }
 
 func (l *logger) Warnf(ctx context.Context, message string, fs *Fields) {
-	fs.fields = append(fs.fields, l.appNameField)
-	_, msg, fields := l.executeMiddleLayers(ctx, message, fs)
-	l.zapLogger.Warn(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.WarnLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.WarnLevel, message) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, message, l.entryFields(ctx, state, fs))
+	l.zapLogger(state).Warn(msg, fields.fields...)
 }
 
 func (l *logger) Errorf(ctx context.Context, msg string, fields *Fields) {
-	fields.fields = append(fields.fields, l.appNameField)
-	_, msg, fields = l.executeMiddleLayers(ctx, msg, fields)
-	l.zapLogger.Error(msg, fields.fields...)
+	if !l.levels.enabled(zapcore.ErrorLevel) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(zapcore.ErrorLevel, msg) {
+		return
+	}
+	_, msg, fields = l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fields))
+	l.zapLogger(state).Error(msg, fields.fields...)
 }
 
 func (l *logger) Fatalf(ctx context.Context, msg string, fields *Fields) {
-	fields.fields = append(fields.fields, l.appNameField)
-	_, msg, fields = l.executeMiddleLayers(ctx, msg, fields)
-	l.zapLogger.Fatal(msg, fields.fields...)
+	state := l.state.Load()
+	_, msg, fields = l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fields))
+	l.zapLogger(state).Fatal(msg, fields.fields...)
 }
+
 func (l *logger) Panicf(ctx context.Context, msg string, fields *Fields) {
-	fields.fields = append(fields.fields, l.appNameField)
-	_, msg, fields = l.executeMiddleLayers(ctx, msg, fields)
-	l.zapLogger.Panic(msg, fields.fields...)
+	state := l.state.Load()
+	_, msg, fields = l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fields))
+	l.zapLogger(state).Panic(msg, fields.fields...)
 }
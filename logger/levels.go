@@ -1,6 +1,15 @@
 package logger
 
-import "go.uber.org/zap/zapcore"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
 type LogLevel string
 
@@ -34,3 +43,204 @@ var logLevelToZapLevelMap = map[LogLevel]zapcore.Level{
 	PanicLevel: zapcore.PanicLevel,
 	FatalLevel: zapcore.FatalLevel,
 }
+
+var zapLevelToLogLevelMap = map[zapcore.Level]LogLevel{
+	zapcore.DebugLevel: DebugLevel,
+	zapcore.InfoLevel:  InfoLevel,
+	zapcore.WarnLevel:  WarnLevel,
+	zapcore.ErrorLevel: ErrorLevel,
+	zapcore.PanicLevel: PanicLevel,
+	zapcore.FatalLevel: FatalLevel,
+}
+
+// LevelConfig is the runtime level configuration of a logger.
+// Level : level of the packages without an override
+// Packages : level overrides by package path, an override also applies to the sub packages, the longest matching path wins
+// e.g. {"github.com/gofreego/goutils/configutils": "debug"}
+// RevertAt : time the levels revert to the configuration before the temporary change, set only while a change with a ttl is active
+type LevelConfig struct {
+	Level    LogLevel            `json:"level"`
+	Packages map[string]LogLevel `json:"packages,omitempty"`
+	RevertAt *time.Time          `json:"revertAt,omitempty"`
+}
+
+// levels holds the level of a logger, shared by the zap loggers it builds, so that it can be changed safely while logging.
+// The per-package overrides are matched against the caller of the log entry.
+type levels struct {
+	level    zap.AtomicLevel
+	packages atomic.Pointer[map[string]zapcore.Level]
+	// min is the lowest level of the overrides, entries below min(level, min) are dropped without resolving the caller
+	min atomic.Int32
+
+	mu       sync.Mutex
+	revert   *time.Timer
+	revertAt time.Time
+	// base is the configuration restored when a temporary change expires
+	base *LevelConfig
+}
+
+func newLevels(level zapcore.Level) *levels {
+	l := &levels{level: zap.NewAtomicLevelAt(level)}
+	l.setPackages(nil)
+	return l
+}
+
+func (l *levels) setPackages(packages map[string]zapcore.Level) {
+	min := zapcore.FatalLevel
+	for _, level := range packages {
+		if level < min {
+			min = level
+		}
+	}
+	l.packages.Store(&packages)
+	l.min.Store(int32(min))
+}
+
+// enabled reports whether entries of the level can be written by any package.
+func (l *levels) enabled(level zapcore.Level) bool {
+	return level >= l.level.Level() || level >= zapcore.Level(l.min.Load())
+}
+
+// enabledFor reports whether the entry of the level logged from caller is written.
+func (l *levels) enabledFor(level zapcore.Level, caller zapcore.EntryCaller) bool {
+	packages := *l.packages.Load()
+	if len(packages) == 0 || !caller.Defined {
+		return level >= l.level.Level()
+	}
+	pkg := callerPackage(caller.Function)
+	match, threshold := "", l.level.Level()
+	for path, override := range packages {
+		if len(path) > len(match) && (pkg == path || strings.HasPrefix(pkg, path+"/")) {
+			match, threshold = path, override
+		}
+	}
+	return level >= threshold
+}
+
+// callerPackage returns the package path of the function, e.g. github.com/gofreego/goutils/configutils/impls/consul.(*ConsulConfigReader).Read -> github.com/gofreego/goutils/configutils/impls/consul
+func callerPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// config returns the current level configuration.
+func (l *levels) config() LevelConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.configLocked()
+}
+
+func (l *levels) configLocked() LevelConfig {
+	config := LevelConfig{Level: zapLevelToLogLevelMap[l.level.Level()]}
+	if packages := *l.packages.Load(); len(packages) > 0 {
+		config.Packages = make(map[string]LogLevel, len(packages))
+		for path, level := range packages {
+			config.Packages[path] = zapLevelToLogLevelMap[level]
+		}
+	}
+	if l.revert != nil {
+		revertAt := l.revertAt
+		config.RevertAt = &revertAt
+	}
+	return config
+}
+
+// set applies the level configuration, with a ttl > 0 it is reverted to the configuration before the first pending temporary change after ttl.
+// A change without ttl cancels the pending revert.
+func (l *levels) set(config LevelConfig, ttl time.Duration) error {
+	level, found := logLevelToZapLevelMap[config.Level]
+	if !found {
+		return fmt.Errorf("invalid log level %q", config.Level)
+	}
+	packages := make(map[string]zapcore.Level, len(config.Packages))
+	for path, pkgLevel := range config.Packages {
+		zapLevel, found := logLevelToZapLevelMap[pkgLevel]
+		if !found {
+			return fmt.Errorf("invalid log level %q for package %s", pkgLevel, path)
+		}
+		packages[strings.TrimSuffix(path, "/")] = zapLevel
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.revert != nil {
+		l.revert.Stop()
+		l.revert = nil
+	}
+	if ttl > 0 {
+		if l.base == nil {
+			base := l.configLocked()
+			l.base = &base
+		}
+		base := *l.base
+		l.revertAt = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			// a later change replaced this timer
+			if l.revert != timer {
+				return
+			}
+			l.apply(base)
+			l.revert, l.base = nil, nil
+		})
+		l.revert = timer
+	} else {
+		l.base = nil
+	}
+	l.level.SetLevel(level)
+	l.setPackages(packages)
+	return nil
+}
+
+// apply applies a configuration which is already validated.
+func (l *levels) apply(config LevelConfig) {
+	packages := make(map[string]zapcore.Level, len(config.Packages))
+	for path, level := range config.Packages {
+		packages[path] = logLevelToZapLevelMap[level]
+	}
+	l.level.SetLevel(logLevelToZapLevelMap[config.Level])
+	l.setPackages(packages)
+}
+
+// levelCore filters the entries of the wrapped core with the levels.
+// The stack traces of the error fields are removed from the entries below stackLevel.
+type levelCore struct {
+	zapcore.Core
+	levels     *levels
+	stackLevel zapcore.Level
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels, stackLevel: c.stackLevel}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.levels.enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// Write drops the entries below the level of the caller package, the caller is only known once the entry is checked.
+// The entry is checked against the wrapped core, so that the levels of its sinks apply.
+func (c *levelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.levels.enabledFor(entry.Level, entry.Caller) {
+		return nil
+	}
+	if entry.Level < c.stackLevel {
+		fields = withoutErrorStacks(fields)
+	}
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder encodes the entries as logfmt, key=value pairs separated by spaces.
+// The nested objects are flattened with dotted keys, the arrays are written as json.
+type logfmtEncoder struct {
+	// MapObjectEncoder holds the fields added by With, they are written sorted by key
+	*zapcore.MapObjectEncoder
+	config zapcore.EncoderConfig
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), config: config}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), config: e.config}
+	for key, value := range e.Fields {
+		clone.Fields[key] = value
+	}
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf := logfmtPool.Get()
+	if e.config.TimeKey != zapcore.OmitKey && e.config.TimeKey != "" {
+		appendLogfmt(buf, e.config.TimeKey, encodeTime(e.config, entry.Time))
+	}
+	if e.config.LevelKey != zapcore.OmitKey && e.config.LevelKey != "" {
+		level := any(entry.Level.String())
+		if e.config.EncodeLevel != nil {
+			level = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+				e.config.EncodeLevel(entry.Level, enc)
+			})
+		}
+		appendLogfmt(buf, e.config.LevelKey, level)
+	}
+	if entry.LoggerName != "" && e.config.NameKey != zapcore.OmitKey && e.config.NameKey != "" {
+		appendLogfmt(buf, e.config.NameKey, entry.LoggerName)
+	}
+	if entry.Caller.Defined && e.config.CallerKey != zapcore.OmitKey && e.config.CallerKey != "" {
+		appendLogfmt(buf, e.config.CallerKey, entry.Caller.TrimmedPath())
+	}
+	if e.config.MessageKey != zapcore.OmitKey && e.config.MessageKey != "" {
+		appendLogfmt(buf, e.config.MessageKey, entry.Message)
+	}
+	appendLogfmtMap(buf, "", e.Fields)
+	// the fields of the entry keep their order, a field adding several keys is sorted
+	for _, field := range fields {
+		m := zapcore.NewMapObjectEncoder()
+		field.AddTo(m)
+		appendLogfmtMap(buf, "", m.Fields)
+	}
+	if entry.Stack != "" && e.config.StacktraceKey != zapcore.OmitKey && e.config.StacktraceKey != "" {
+		appendLogfmt(buf, e.config.StacktraceKey, entry.Stack)
+	}
+	buf.AppendString(e.lineEnding())
+	return buf, nil
+}
+
+func (e *logfmtEncoder) lineEnding() string {
+	if e.config.LineEnding == "" {
+		return zapcore.DefaultLineEnding
+	}
+	return e.config.LineEnding
+}
+
+func appendLogfmtMap(buf *buffer.Buffer, prefix string, fields map[string]any) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		appendLogfmt(buf, prefix+key, fields[key])
+	}
+}
+
+func appendLogfmt(buf *buffer.Buffer, key string, value any) {
+	if nested, ok := value.(map[string]any); ok {
+		appendLogfmtMap(buf, key+".", nested)
+		return
+	}
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+	buf.AppendString(logfmtKey(key))
+	buf.AppendByte('=')
+	buf.AppendString(logfmtValue(value))
+}
+
+// logfmtKey replaces the characters a key cannot have, spaces, = and quotes.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtValue(value any) string {
+	var str string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case time.Time:
+		str = v.Format(time.RFC3339Nano)
+	case time.Duration:
+		str = v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, complex64, complex128:
+		return fmt.Sprint(v)
+	case error:
+		str = v.Error()
+	default:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			str = fmt.Sprint(v)
+		} else {
+			str = string(bytes)
+		}
+	}
+	if needsQuote(str) {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
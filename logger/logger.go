@@ -3,62 +3,126 @@ package logger
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 )
 
 var (
-	internalLogger Logger
+	// internalLogger is the global logger of the package functions, it is swapped atomically by InitiateLogger and ReplaceGlobal
+	internalLogger atomic.Pointer[Logger]
 )
 
 func init() {
 	// Initialize the logger with default configuration
-	var err error
-	internalLogger, err = NewLogger(&Config{AppName: "default", Build: "dev", skipLevels: 2})
+	l, err := NewLogger(&Config{AppName: "default", Build: "dev", global: true})
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
+	internalLogger.Store(&l)
+}
+
+func global() Logger {
+	return *internalLogger.Load()
 }
 
 func (c Config) InitiateLogger() error {
-	var err error
-	c.skipLevels = 2
-	internalLogger, err = NewLogger(&c)
-	return err
+	c.global = true
+	newLogger, err := NewLogger(&c)
+	if err != nil {
+		return err
+	}
+	previous := *internalLogger.Swap(&newLogger)
+	// release the sinks of the replaced logger
+	if l, ok := previous.(*logger); ok {
+		l.Sync()
+		l.state.Load().close()
+	}
+	return nil
+}
+
+// ReplaceGlobal replaces the global logger of the package functions and returns a function restoring the previous one, e.g. to capture the entries in tests.
+// Unlike InitiateLogger the previous logger is not closed.
+func ReplaceGlobal(l Logger) (restore func()) {
+	switch impl := l.(type) {
+	case *logger:
+		l = &logger{loggerBase: impl.loggerBase, fields: impl.fields, global: true}
+	case *slogLogger:
+		l = &slogLogger{handler: impl.handler, middleLayers: impl.middleLayers, fields: impl.fields, global: true}
+	}
+	previous := internalLogger.Swap(&l)
+	return func() {
+		internalLogger.Store(previous)
+	}
+}
+
+// With returns a child of the global logger adding the fields to every entry.
+// The child follows the configuration changes of the global logger, but not its replacement by InitiateLogger.
+func With(fields ...*Field) Logger {
+	return global().With(fields...)
 }
 
 func AddMiddleLayers(middlelayers ...MiddleLayer) {
-	internalLogger.AddMiddleLayers(middlelayers...)
+	global().AddMiddleLayers(middlelayers...)
+}
+
+// GetLevels returns the runtime level configuration of the global logger.
+func GetLevels() LevelConfig {
+	return global().Levels()
+}
+
+// SetLevels changes the level and the per-package level overrides of the global logger at runtime.
+// With a ttl > 0 the levels revert to the configuration before the change after ttl.
+func SetLevels(config LevelConfig, ttl time.Duration) error {
+	return global().SetLevels(config, ttl)
+}
+
+// Sync flushes the buffered entries of the global logger, call it before the application exits.
+func Sync() error {
+	return global().Sync()
 }
 
 func Info(ctx context.Context, format string, a ...any) {
-	internalLogger.Info(ctx, format, a...)
+	global().Info(ctx, format, a...)
 }
 
 func Infof(ctx context.Context, format string, fields *Fields) {
-	internalLogger.Infof(ctx, format, fields)
+	global().Infof(ctx, format, fields)
 }
 
 func Infow(ctx context.Context, message string, fs *Fields) {
-	internalLogger.Infof(ctx, message, fs)
+	global().Infof(ctx, message, fs)
+}
+
+func Debugf(ctx context.Context, message string, fields *Fields) {
+	global().Debugf(ctx, message, fields)
+}
+
+func Warnf(ctx context.Context, message string, fields *Fields) {
+	global().Warnf(ctx, message, fields)
+}
+
+func Errorf(ctx context.Context, message string, fields *Fields) {
+	global().Errorf(ctx, message, fields)
 }
 
 func Error(ctx context.Context, format string, a ...any) {
-	internalLogger.Error(ctx, format, a...)
+	global().Error(ctx, format, a...)
 }
 
 func Warn(ctx context.Context, format string, a ...any) {
-	internalLogger.Warn(ctx, format, a...)
+	global().Warn(ctx, format, a...)
 }
 
 func Debug(ctx context.Context, format string, a ...any) {
-	internalLogger.Debug(ctx, format, a...)
+	global().Debug(ctx, format, a...)
 }
 
 func Panic(ctx context.Context, format string, a ...any) {
-	internalLogger.Panic(ctx, format, a...)
+	global().Panic(ctx, format, a...)
 }
 
 func Fatal(ctx context.Context, format string, a ...any) {
-	internalLogger.Fatal(ctx, format, a...)
+	global().Fatal(ctx, format, a...)
 }
 
 type BaseLogger interface {
@@ -78,7 +142,11 @@ type Logger interface {
 	Warnf(ctx context.Context, format string, fields *Fields)
 	Panicf(ctx context.Context, format string, fields *Fields)
 	Fatalf(ctx context.Context, format string, fields *Fields)
+	With(fields ...*Field) Logger
 	AddMiddleLayers(middlelayers ...MiddleLayer)
 	ReplaceMiddleLayers(middlelayers ...MiddleLayer)
 	ChangeConfig(config *Config) error
+	Levels() LevelConfig
+	SetLevels(config LevelConfig, ttl time.Duration) error
+	Sync() error
 }
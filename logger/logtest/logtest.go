@@ -0,0 +1,123 @@
+// Package logtest captures the entries of a logger in memory, so that tests can assert on them.
+//
+//	func TestCreateOrder(t *testing.T) {
+//		logs := logtest.Replace(t)
+//		service.CreateOrder(ctx, order)
+//		logs.AssertLogged(t, logger.ErrorLevel, "payment failed")
+//	}
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gofreego/goutils/logger"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Entry is a captured log entry, its fields include the fields added by the middle-layers and the App field.
+type Entry struct {
+	Level   logger.LogLevel
+	Message string
+	Fields  map[string]any
+	// Caller : file:line of the code which logged the entry
+	Caller string
+}
+
+// Logger is a logger.Logger capturing its entries in memory, at debug level.
+type Logger struct {
+	logger.Logger
+	logs *observer.ObservedLogs
+}
+
+// New returns a logger capturing its entries, the middle-layers apply as for logger.NewLogger.
+func New(middleLayers ...logger.MiddleLayer) *Logger {
+	l, err := NewWithConfig(&logger.Config{AppName: "test", Build: logger.BuildDev, Level: logger.DebugLevel}, middleLayers...)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create the test logger: %v", err))
+	}
+	return l
+}
+
+// NewWithConfig returns a logger capturing its entries, the config applies except for the sinks, e.g. to test the rate limit or the redaction.
+func NewWithConfig(config *logger.Config, middleLayers ...logger.MiddleLayer) (*Logger, error) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l, err := logger.NewCoreLogger(config, core, middleLayers...)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{Logger: l, logs: logs}, nil
+}
+
+// Replace replaces the global logger of the package functions by a capturing logger until the end of the test.
+// The global logger is shared by the whole test binary, so the tests using Replace must not run in parallel.
+func Replace(t testing.TB, middleLayers ...logger.MiddleLayer) *Logger {
+	t.Helper()
+	l := New(middleLayers...)
+	restore := logger.ReplaceGlobal(l.Logger)
+	t.Cleanup(restore)
+	return l
+}
+
+// Entries returns the captured entries in the order they were logged.
+func (l *Logger) Entries() []Entry {
+	logged := l.logs.All()
+	entries := make([]Entry, 0, len(logged))
+	for _, entry := range logged {
+		entries = append(entries, Entry{
+			Level:   logger.LogLevel(entry.Level.String()),
+			Message: entry.Message,
+			Fields:  entry.ContextMap(),
+			Caller:  entry.Caller.TrimmedPath(),
+		})
+	}
+	return entries
+}
+
+// Filter returns the captured entries of the level with a message containing msgSubstring, an empty level matches all levels.
+func (l *Logger) Filter(level logger.LogLevel, msgSubstring string) []Entry {
+	var entries []Entry
+	for _, entry := range l.Entries() {
+		if (level == "" || entry.Level == level) && strings.Contains(entry.Message, msgSubstring) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Reset discards the captured entries.
+func (l *Logger) Reset() {
+	l.logs.TakeAll()
+}
+
+// AssertLogged fails the test if no entry of the level has a message containing msgSubstring, it returns the first matching entry.
+func (l *Logger) AssertLogged(t testing.TB, level logger.LogLevel, msgSubstring string) Entry {
+	t.Helper()
+	entries := l.Filter(level, msgSubstring)
+	if len(entries) == 0 {
+		t.Errorf("no %s entry with message containing %q was logged, the entries are:\n%s", level, msgSubstring, l.summary())
+		return Entry{}
+	}
+	return entries[0]
+}
+
+// AssertNotLogged fails the test if an entry of the level has a message containing msgSubstring.
+func (l *Logger) AssertNotLogged(t testing.TB, level logger.LogLevel, msgSubstring string) {
+	t.Helper()
+	if entries := l.Filter(level, msgSubstring); len(entries) > 0 {
+		t.Errorf("%d %s entries with message containing %q were logged, the entries are:\n%s", len(entries), level, msgSubstring, l.summary())
+	}
+}
+
+func (l *Logger) summary() string {
+	var builder strings.Builder
+	for _, entry := range l.Entries() {
+		fmt.Fprintf(&builder, "\t%s %s %v\n", entry.Level, entry.Message, entry.Fields)
+	}
+	if builder.Len() == 0 {
+		return "\tnone\n"
+	}
+	return builder.String()
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 	"net/http"
 	"time"
-
-	"github.com/google/uuid"
 )
 
+// RequestMiddleLayer adds the fields of the request context, set by the request middlewares.
+// Without a request context the fields are read from the incoming grpc metadata, if any.
 func RequestMiddleLayer(ctx context.Context, msg string, fields *Fields) (context.Context, string, *Fields) {
-	vRc, rcOk := ctx.Value(RequestContextKey).(RequestContext)
+	vRc, rcOk := RequestContextFromContext(ctx)
 	if !rcOk {
-		return ctx, msg, fields
+		if vRc, rcOk = grpcRequestContext(ctx); !rcOk {
+			return ctx, msg, fields
+		}
 	}
 	if vRc.RequestID != "" {
 		fields.AddField(requestIDKey, vRc.RequestID)
@@ -34,16 +36,14 @@ func RequestMiddleLayer(ctx context.Context, msg string, fields *Fields) (contex
 	return ctx, msg, fields
 }
 
-// Middleware to add Request ID
+// Middleware to add the request context, the request id, client and user id are read from the constants headers
+// It also propagates the W3C traceparent header, the span context is set in the context of the request and its traceparent on the response.
 func WithRequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
-		}
-		// set the request id in context
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, RequestContextKey, RequestContext{RequestID: requestID, URI: r.RequestURI, Method: r.Method, IP: r.RemoteAddr})
+		// set the request context in context
+		ctx := ExtractTraceContext(r.Context(), r.Header)
+		InjectTraceContext(ctx, w.Header())
+		ctx = WithRequestContext(ctx, NewHTTPRequestContext(r))
 		r = r.WithContext(ctx) // update the request with the new context
 		next.ServeHTTP(w, r)
 	})
@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gofreego/goutils/cf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	RedactEmail  = "email"
+	RedactMobile = "mobile"
+	RedactCard   = "card"
+	RedactBearer = "bearer"
+	// RedactNone disables the pattern matching, only the configured keys are masked
+	RedactNone = "none"
+
+	defaultRedactionMask = "******"
+)
+
+var (
+	defaultRedactionKeys = []string{"password", "passwd", "secret", "token", "apikey", "authorization", "cookie"}
+
+	emailCandidateRegex  = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	mobileCandidateRegex = regexp.MustCompile(`(?:\+|\b)\(?\d[\d\-\(\)]{8,17}\d\b`)
+	cardCandidateRegex   = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	bearerRegex          = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+)
+
+// RedactionConfig : masking of personal data and secrets in the messages and fields, before they are written
+// Enabled : enables the redaction of every log entry, after the middle-layers
+// Keys : field keys whose values are always masked, also the keys of nested maps and structs.
+// keys match case-insensitively ignoring - and _ if they contain a configured key, default password, passwd, secret, token, apikey, authorization, cookie
+// Patterns : values masked wherever they appear, any of email, mobile, card, bearer, default all of them, none disables the patterns
+// Mask : replacement of the masked values, default ******
+type RedactionConfig struct {
+	Enabled  bool     `yaml:"Enabled" json:"Enabled" name:"Enabled" type:"bool" description:"Enable Redaction"`
+	Keys     []string `yaml:"Keys" json:"Keys" name:"Keys" description:"Field Keys to Mask"`
+	Patterns []string `yaml:"Patterns" json:"Patterns" name:"Patterns" description:"Value Patterns to Mask" choices:"email,mobile,card,bearer,none"`
+	Mask     string   `yaml:"Mask" json:"Mask" name:"Mask" type:"string" description:"Mask of the Redacted Values" default:"******"`
+}
+
+// redactor masks the configured keys and the pattern-matched values.
+type redactor struct {
+	keys                        []string
+	mask                        string
+	email, mobile, card, bearer bool
+}
+
+func newRedactor(config RedactionConfig) *redactor {
+	if !config.Enabled {
+		return nil
+	}
+	r := &redactor{mask: config.Mask}
+	if r.mask == "" {
+		r.mask = defaultRedactionMask
+	}
+	keys := config.Keys
+	if len(keys) == 0 {
+		keys = defaultRedactionKeys
+	}
+	for _, key := range keys {
+		r.keys = append(r.keys, normalizeKey(key))
+	}
+	patterns := config.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{RedactEmail, RedactMobile, RedactCard, RedactBearer}
+	}
+	for _, pattern := range patterns {
+		switch strings.ToLower(pattern) {
+		case RedactEmail:
+			r.email = true
+		case RedactMobile:
+			r.mobile = true
+		case RedactCard:
+			r.card = true
+		case RedactBearer:
+			r.bearer = true
+		}
+	}
+	return r
+}
+
+// RedactionMiddleLayer returns a middle-layer masking the message and the fields as configured, Enabled is implied.
+// Config.Redaction applies the same redaction after every middle-layer, use this to redact at a specific position of the middle-layers.
+func RedactionMiddleLayer(config RedactionConfig) MiddleLayer {
+	config.Enabled = true
+	r := newRedactor(config)
+	return func(ctx context.Context, msg string, fields *Fields) (context.Context, string, *Fields) {
+		msg, fields = r.redact(msg, fields)
+		return ctx, msg, fields
+	}
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	key = normalizeKey(key)
+	for _, sensitive := range r.keys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact masks the message and the fields, a nil redactor returns them as they are.
+func (r *redactor) redact(msg string, fields *Fields) (string, *Fields) {
+	if r == nil {
+		return msg, fields
+	}
+	msg = r.redactString(msg)
+	if fields == nil {
+		return msg, fields
+	}
+	for i, field := range fields.fields {
+		fields.fields[i] = r.redactField(field)
+	}
+	return msg, fields
+}
+
+func (r *redactor) redactField(field zapcore.Field) zapcore.Field {
+	if r.sensitiveKey(field.Key) {
+		return zap.String(field.Key, r.mask)
+	}
+	if obj, ok := field.Interface.(errorObject); ok {
+		obj.redact = r.redactString
+		return zap.Object(field.Key, obj)
+	}
+	switch field.Type {
+	case zapcore.StringType:
+		if redacted := r.redactString(field.String); redacted != field.String {
+			return zap.String(field.Key, redacted)
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			if msg := err.Error(); r.redactString(msg) != msg {
+				return zap.String(field.Key, r.redactString(msg))
+			}
+		}
+	case zapcore.StringerType:
+		if stringer, ok := field.Interface.(interface{ String() string }); ok {
+			if str := stringer.String(); r.redactString(str) != str {
+				return zap.String(field.Key, r.redactString(str))
+			}
+		}
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		// e.g. zap.Strings, redacted through the generic value of their encoding
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		value, ok := enc.Fields[field.Key]
+		if !ok {
+			return field
+		}
+		redacted := r.redactValue(value)
+		if !reflect.DeepEqual(redacted, value) || r.hasSensitiveKeys(value) {
+			return zap.Any(field.Key, redacted)
+		}
+	case zapcore.ReflectType:
+		// maps, structs and slices are redacted through their json form
+		bytes, err := json.Marshal(field.Interface)
+		if err != nil {
+			return field
+		}
+		var value any
+		if err := json.Unmarshal(bytes, &value); err != nil {
+			return field
+		}
+		redacted := r.redactValue(value)
+		if !reflect.DeepEqual(redacted, value) || r.hasSensitiveKeys(value) {
+			return zap.Any(field.Key, redacted)
+		}
+	}
+	return field
+}
+
+// hasSensitiveKeys reports whether value has a sensitive key, redactValue masks them in place.
+func (r *redactor) hasSensitiveKeys(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if (r.sensitiveKey(key) && item != r.mask) || r.hasSensitiveKeys(item) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if r.hasSensitiveKeys(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactValue returns a copy of the generic json value with the sensitive keys and the pattern-matched strings masked.
+func (r *redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			if r.sensitiveKey(key) {
+				redacted[key] = r.mask
+			} else {
+				redacted[key] = r.redactValue(item)
+			}
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.redactValue(item)
+		}
+		return redacted
+	case string:
+		return r.redactString(v)
+	}
+	return value
+}
+
+// redactString masks the bearer tokens, card numbers, emails and mobile numbers in s.
+func (r *redactor) redactString(s string) string {
+	if r.bearer {
+		s = bearerRegex.ReplaceAllString(s, "Bearer "+r.mask)
+	}
+	if r.card {
+		s = cardCandidateRegex.ReplaceAllStringFunc(s, func(match string) string {
+			if luhnValid(match) {
+				return r.mask
+			}
+			return match
+		})
+	}
+	if r.email {
+		s = emailCandidateRegex.ReplaceAllStringFunc(s, func(match string) string {
+			if cf.IsEmail(match) {
+				return r.mask
+			}
+			return match
+		})
+	}
+	if r.mobile {
+		s = mobileCandidateRegex.ReplaceAllStringFunc(s, func(match string) string {
+			if cf.IsMobile(match) && mobileDigits(match) {
+				return r.mask
+			}
+			return match
+		})
+	}
+	return s
+}
+
+// mobileDigits reports whether the digits of s can be a mobile number, so that dates, timestamps and ids are kept.
+// International numbers have a + and 10 to 15 digits, national numbers 10 digits not starting with 0 or 1, or 11 digits with the trunk prefix 0.
+func mobileDigits(s string) bool {
+	digits := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits = append(digits, s[i])
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "+"):
+		return len(digits) >= 10 && len(digits) <= 15
+	case len(digits) == 10:
+		return digits[0] >= '2'
+	case len(digits) == 11:
+		return digits[0] == '0'
+	}
+	return false
+}
+
+// luhnValid reports whether the digits of s pass the luhn checksum of card numbers.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
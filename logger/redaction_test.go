@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedactString(t *testing.T) {
+	r := newRedactor(RedactionConfig{Enabled: true})
+	tests := []struct {
+		in, want string
+	}{
+		{in: "mail alice@example.com", want: "mail ******"},
+		{in: "call 9876543210", want: "call ******"},
+		{in: "call +91 9876543210 or +919876543210", want: "call +91 ****** or ******"},
+		{in: "call 09876543210", want: "call ******"},
+		{in: "created at 1697712345123", want: "created at 1697712345123"},
+		{in: "created at 1697712345", want: "created at 1697712345"},
+		{in: "date 2026-10-19", want: "date 2026-10-19"},
+		{in: "order ORD12345678901", want: "order ORD12345678901"},
+		{in: "id 123456789012345678901", want: "id 123456789012345678901"},
+		{in: "card 4111 1111 1111 1111", want: "card ******"},
+		{in: "Authorization: Bearer abc.def", want: "Authorization: Bearer ******"},
+	}
+	for _, test := range tests {
+		if got := r.redactString(test.in); got != test.want {
+			t.Errorf("redactString(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestRedactField(t *testing.T) {
+	r := newRedactor(RedactionConfig{Enabled: true})
+	tests := []struct {
+		name  string
+		field zapcore.Field
+		want  any
+	}{
+		{name: "sensitive key", field: zap.String("password", "p"), want: "******"},
+		{name: "string", field: zap.String("to", "alice@example.com"), want: "******"},
+		{name: "strings", field: zap.Strings("emails", []string{"alice@example.com", "bob"}), want: []any{"******", "bob"}},
+		{name: "ints", field: zap.Int64s("ids", []int64{1697712345123}), want: []any{int64(1697712345123)}},
+		{name: "object", field: zap.Dict("user", zap.String("email", "alice@example.com"), zap.String("token", "t")), want: map[string]any{"email": "******", "token": "******"}},
+		{name: "reflect", field: zap.Any("users", []map[string]string{{"email": "alice@example.com"}}), want: []any{map[string]any{"email": "******"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			r.redactField(test.field).AddTo(enc)
+			if got := enc.Fields[test.field.Key]; !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("redactField() = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofreego/goutils/constants"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// WithRequestContext returns a context carrying the request context, RequestMiddleLayer adds its fields to the log lines.
+func WithRequestContext(ctx context.Context, rc RequestContext) context.Context {
+	return context.WithValue(ctx, RequestContextKey, rc)
+}
+
+// RequestContextFromContext returns the request context set by WithRequestContext, a *RequestContext value is accepted too.
+func RequestContextFromContext(ctx context.Context) (RequestContext, bool) {
+	switch rc := ctx.Value(RequestContextKey).(type) {
+	case RequestContext:
+		return rc, true
+	case *RequestContext:
+		if rc != nil {
+			return *rc, true
+		}
+	}
+	return RequestContext{}, false
+}
+
+// NewHTTPRequestContext returns the request context of the constants headers of the request,
+// the request id is generated if the x-request-id header is missing, the client is the x-client-id header or else the user agent.
+func NewHTTPRequestContext(r *http.Request) RequestContext {
+	requestID := r.Header.Get(constants.X_REQUEST_ID)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	client := r.Header.Get(constants.HEADER_CLIENT_ID)
+	if client == "" {
+		client = r.Header.Get(constants.HEADER_USER_AGENT)
+	}
+	return RequestContext{
+		RequestID: requestID,
+		Client:    client,
+		UserID:    r.Header.Get(constants.USER_ID),
+		Method:    r.Method,
+		URI:       r.RequestURI,
+		IP:        r.RemoteAddr,
+	}
+}
+
+// NewGRPCRequestContext returns the request context of the constants headers in the incoming grpc metadata of ctx,
+// the method is the full grpc method and the ip the address of the peer.
+// The request id is generated if the x-request-id metadata is missing.
+func NewGRPCRequestContext(ctx context.Context) RequestContext {
+	rc, _ := grpcRequestContext(ctx)
+	if rc.RequestID == "" {
+		rc.RequestID = uuid.New().String()
+	}
+	return rc
+}
+
+// grpcRequestContext returns the request context of the incoming grpc metadata of ctx, false without metadata.
+func grpcRequestContext(ctx context.Context) (RequestContext, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	rc := RequestContext{
+		RequestID: get(constants.X_REQUEST_ID),
+		Client:    get(constants.HEADER_CLIENT_ID),
+		UserID:    get(constants.USER_ID),
+		Method:    http.MethodPost,
+	}
+	if rc.Client == "" {
+		rc.Client = get(constants.HEADER_USER_AGENT)
+	}
+	if method, ok := grpc.Method(ctx); ok {
+		rc.URI = method
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		rc.IP = p.Addr.String()
+	}
+	return rc, ok
+}
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// rateLimitBuckets is the number of counters of the rate limiter, templates hashing into the same bucket share it
+	rateLimitBuckets = 4096
+	// maxSuppressedKeys caps the distinct keys counted between two summaries, the entries of the other keys are counted together
+	maxSuppressedKeys = 1000
+	// otherSuppressedMessage is the message the entries over maxSuppressedKeys are counted under
+	otherSuppressedMessage = "other messages"
+)
+
+// SamplingConfig : the first Initial entries with the same key are logged every Tick, then every Thereafter-th
+// Initial : entries logged per key every Tick, 0 uses the default, negative disables the sampling
+// Thereafter : after Initial entries, every Thereafter-th entry is logged, 0 drops all of them
+// Tick : interval the counters are reset at, default 1s
+type SamplingConfig struct {
+	Initial    int           `yaml:"Initial" json:"Initial" name:"Initial" type:"int" description:"Entries Logged per Tick"`
+	Thereafter int           `yaml:"Thereafter" json:"Thereafter" name:"Thereafter" type:"int" description:"Every Nth Entry Logged after Initial"`
+	Tick       time.Duration `yaml:"Tick" json:"Tick" name:"Tick" type:"duration" description:"Sampling Interval" default:"1s"`
+}
+
+func (c SamplingConfig) enabled() bool {
+	return c.Initial > 0
+}
+
+func (c SamplingConfig) tick() time.Duration {
+	if c.Tick <= 0 {
+		return time.Second
+	}
+	return c.Tick
+}
+
+// zapSampling returns the zap sampling of the config, zap samples by level and message.
+// It defaults to the zap production sampling, 100 then every 100th per second, for prod builds.
+func zapSampling(config *Config) SamplingConfig {
+	sampling := config.Sampling
+	if sampling.Initial == 0 && config.Build == BuildProd {
+		sampling.Initial, sampling.Thereafter = 100, 100
+	}
+	return sampling
+}
+
+// counter counts the entries of a key within the current tick.
+type counter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+func (c *counter) incCheckReset(now time.Time, tick time.Duration) uint64 {
+	tn := now.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > tn {
+		return c.count.Add(1)
+	}
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, tn+tick.Nanoseconds()) {
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// allowed reports whether the n-th entry of a tick is logged.
+func (c SamplingConfig) allowed(n uint64) bool {
+	if n <= uint64(c.Initial) {
+		return true
+	}
+	return c.Thereafter > 0 && (n-uint64(c.Initial))%uint64(c.Thereafter) == 0
+}
+
+// rateLimiter limits the entries per level and message template, before the message is formatted,
+// so that a flood of the same log line with different arguments is limited as one.
+type rateLimiter struct {
+	config   SamplingConfig
+	counters [zapcore.FatalLevel - zapcore.DebugLevel + 1][rateLimitBuckets]counter
+}
+
+func newRateLimiter(config SamplingConfig) *rateLimiter {
+	if !config.enabled() {
+		return nil
+	}
+	return &rateLimiter{config: config}
+}
+
+// allow reports whether the entry is logged, a nil limiter allows every entry.
+func (r *rateLimiter) allow(level zapcore.Level, template string) bool {
+	if r == nil || level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(template))
+	c := &r.counters[level-zapcore.DebugLevel][h.Sum32()%rateLimitBuckets]
+	return r.config.allowed(c.incCheckReset(time.Now(), r.config.tick()))
+}
+
+// suppressedKey is the level and the message template of the suppressed entries,
+// the sampling drops formatted messages, so at most maxSuppressedKeys keys are counted.
+type suppressedKey struct {
+	level   zapcore.Level
+	message string
+}
+
+func (k suppressedKey) String() string {
+	return k.level.String() + " " + k.message
+}
+
+// suppressed counts the entries dropped by the sampling and the rate limiter per level and message, and logs a summary every interval.
+type suppressed struct {
+	counts sync.Map // suppressedKey -> *atomic.Uint64
+	keys   atomic.Int64
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newSuppressed() *suppressed {
+	return &suppressed{stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (s *suppressed) add(level zapcore.Level, message string) {
+	key := suppressedKey{level: level, message: message}
+	count, ok := s.counts.Load(key)
+	if !ok {
+		if s.keys.Load() >= maxSuppressedKeys {
+			key.message = otherSuppressedMessage
+		}
+		var loaded bool
+		count, loaded = s.counts.LoadOrStore(key, new(atomic.Uint64))
+		if !loaded {
+			s.keys.Add(1)
+		}
+	}
+	count.(*atomic.Uint64).Add(1)
+}
+
+// run logs the summary of the suppressed entries every interval until Close is called.
+func (s *suppressed) run(zapLogger *zap.Logger, interval time.Duration) {
+	defer close(s.done)
+	zapLogger = zapLogger.WithOptions(zap.WithCaller(false))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.summary(zapLogger)
+		case <-s.stop:
+			s.summary(zapLogger)
+			return
+		}
+	}
+}
+
+func (s *suppressed) summary(zapLogger *zap.Logger) {
+	messages := map[string]uint64{}
+	var total uint64
+	s.counts.Range(func(key, value any) bool {
+		if n := value.(*atomic.Uint64).Swap(0); n > 0 {
+			messages[key.(suppressedKey).String()] = n
+			total += n
+		} else if _, loaded := s.counts.LoadAndDelete(key); loaded {
+			s.keys.Add(-1)
+		}
+		return true
+	})
+	if total > 0 {
+		zapLogger.Warn("suppressed log entries", zap.Uint64("suppressed", total), zap.Any("messages", messages))
+	}
+}
+
+func (s *suppressed) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSuppressedSummary(t *testing.T) {
+	s := newSuppressed()
+	s.add(zapcore.InfoLevel, "user %s logged in")
+	s.add(zapcore.InfoLevel, "user %s logged in")
+	s.add(zapcore.ErrorLevel, "user %s logged in")
+	for i := 0; i < maxSuppressedKeys+10; i++ {
+		s.add(zapcore.WarnLevel, fmt.Sprintf("request %d failed", i))
+	}
+	if got := s.keys.Load(); got != maxSuppressedKeys+1 {
+		t.Fatalf("keys = %d, want %d", got, maxSuppressedKeys+1)
+	}
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	s.summary(zap.New(core))
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("summary logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if got := fields["suppressed"]; got != uint64(maxSuppressedKeys+13) {
+		t.Fatalf("suppressed = %v, want %d", got, maxSuppressedKeys+13)
+	}
+	messages := fields["messages"].(map[string]uint64)
+	if messages["info user %s logged in"] != 2 || messages["error user %s logged in"] != 1 || messages["warn "+otherSuppressedMessage] != 12 {
+		t.Fatalf("messages = %v", messages)
+	}
+
+	// the keys without suppressed entries are dropped at the next summary
+	s.summary(zap.New(core))
+	if got := s.keys.Load(); got != 0 || logs.Len() != 0 {
+		t.Fatalf("keys = %d, logs = %d after an empty summary, want 0", got, logs.Len())
+	}
+}
@@ -0,0 +1,510 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type ShipperFormat string
+
+const (
+	// ShipperOTLP : OTLP/HTTP logs in json, e.g. to an OpenTelemetry collector at http://collector:4318/v1/logs
+	ShipperOTLP ShipperFormat = "otlp"
+	// ShipperJSONLines : one json entry per line
+	ShipperJSONLines ShipperFormat = "jsonl"
+
+	otlpScope = "github.com/gofreego/goutils/logger"
+)
+
+// HTTPSinkConfig : entries pushed to a collector in batches, for the short-lived jobs without a log agent
+// Endpoint : url the batches are posted to
+// Format : otlp or jsonl, default jsonl
+// Headers : headers of the requests, e.g. Authorization
+// BatchSize : maximum entries per request, default 100
+// FlushInterval : maximum time an entry waits for its batch, default 1s
+// Timeout : timeout of a request, default 5s
+// MaxRetries : retries of a batch on network errors, 429 and 5xx, default 3
+// RetryBackoff : wait before the first retry, doubled for the next ones, default 500ms
+// BufferSize : entries waiting to be shipped, default 10000
+// OnFull : drop or block when the buffer is full, default drop. dropped entries are counted and reported on stderr
+// ShutdownTimeout : maximum wait of a flush or Close for the queued entries, the entries left are dropped, default 5s
+type HTTPSinkConfig struct {
+	Endpoint        string            `yaml:"Endpoint" json:"Endpoint" name:"Endpoint" type:"string" description:"Collector URL"`
+	Format          ShipperFormat     `yaml:"Format" json:"Format" name:"Format" type:"choice" description:"Payload Format" choices:"otlp,jsonl" default:"jsonl"`
+	Headers         map[string]string `yaml:"Headers" json:"Headers" name:"Headers" description:"Request Headers"`
+	BatchSize       int               `yaml:"BatchSize" json:"BatchSize" name:"BatchSize" type:"int" description:"Entries per Request" default:"100"`
+	FlushInterval   time.Duration     `yaml:"FlushInterval" json:"FlushInterval" name:"FlushInterval" type:"duration" description:"Maximum Wait of an Entry" default:"1s"`
+	Timeout         time.Duration     `yaml:"Timeout" json:"Timeout" name:"Timeout" type:"duration" description:"Request Timeout" default:"5s"`
+	MaxRetries      int               `yaml:"MaxRetries" json:"MaxRetries" name:"MaxRetries" type:"int" description:"Retries of a Batch" default:"3"`
+	RetryBackoff    time.Duration     `yaml:"RetryBackoff" json:"RetryBackoff" name:"RetryBackoff" type:"duration" description:"Wait before the First Retry" default:"500ms"`
+	BufferSize      int               `yaml:"BufferSize" json:"BufferSize" name:"BufferSize" type:"int" description:"Entries Waiting to be Shipped" default:"10000"`
+	OnFull          string            `yaml:"OnFull" json:"OnFull" name:"OnFull" type:"choice" description:"Behaviour on Full Buffer" choices:"drop,block" default:"drop"`
+	ShutdownTimeout time.Duration     `yaml:"ShutdownTimeout" json:"ShutdownTimeout" name:"ShutdownTimeout" type:"duration" description:"Maximum Wait of a Flush" default:"5s"`
+}
+
+func (c *HTTPSinkConfig) withDefaults() HTTPSinkConfig {
+	config := *c
+	if config.Format == "" {
+		config.Format = ShipperJSONLines
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 500 * time.Millisecond
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = 5 * time.Second
+	}
+	return config
+}
+
+// shipperItem is an encoded entry, or a flush marker if done is set.
+type shipperItem struct {
+	record any
+	done   chan struct{}
+}
+
+// shipper posts the entries to the collector in batches from a background goroutine.
+type shipper struct {
+	config  HTTPSinkConfig
+	appName string
+	client  *http.Client
+	items   chan shipperItem
+	dropped atomic.Uint64
+	// deadline is the unix nano time a flush or Close stops waiting at, the batches left after it are dropped, 0 if none
+	deadline atomic.Int64
+	// stderr gets the failures, the dropped counts and the entries submitted after Close
+	stderr io.Writer
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newShipper(config *HTTPSinkConfig, appName string) (*shipper, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("http sink requires HTTP.Endpoint")
+	}
+	c := config.withDefaults()
+	if c.Format != ShipperOTLP && c.Format != ShipperJSONLines {
+		return nil, fmt.Errorf("invalid http sink format %q", c.Format)
+	}
+	s := &shipper{
+		config:  c,
+		appName: appName,
+		client:  &http.Client{Timeout: c.Timeout},
+		items:   make(chan shipperItem, c.BufferSize),
+		done:    make(chan struct{}),
+		stderr:  os.Stderr,
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]any, 0, s.config.BatchSize)
+	ship := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = make([]any, 0, s.config.BatchSize)
+		}
+	}
+	for {
+		select {
+		case item, ok := <-s.items:
+			if !ok {
+				// Close may have stopped waiting, the entries dropped after it are reported here
+				ship()
+				s.reportDropped()
+				return
+			}
+			if item.done != nil {
+				ship()
+				s.deadline.Store(0)
+				close(item.done)
+				continue
+			}
+			batch = append(batch, item.record)
+			if len(batch) >= s.config.BatchSize {
+				ship()
+			}
+		case <-ticker.C:
+			ship()
+		}
+	}
+}
+
+// submit queues the entry, the entries submitted after Close are written to stderr, the logging path does no network I/O.
+func (s *shipper) submit(record any) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.writeLate(record)
+		return
+	}
+	if s.config.OnFull == "block" {
+		s.items <- shipperItem{record: record}
+		return
+	}
+	select {
+	case s.items <- shipperItem{record: record}:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// flush waits until the queued entries are shipped, at most ShutdownTimeout, and reports the dropped entries.
+func (s *shipper) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		s.reportDropped()
+		return
+	}
+	s.deadline.CompareAndSwap(0, time.Now().Add(s.config.ShutdownTimeout).UnixNano())
+	done := make(chan struct{})
+	select {
+	case s.items <- shipperItem{done: done}:
+	case <-ctx.Done():
+		// the marker could not be queued, nothing resets the deadline
+		s.deadline.Store(0)
+	}
+	s.mu.RUnlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	s.reportDropped()
+}
+
+func (s *shipper) reportDropped() {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		fmt.Fprintf(s.stderr, "logger: dropped %d log entries, http sink buffer full or flush timed out\n", dropped)
+	}
+}
+
+// writeLate writes an entry submitted after Close to stderr.
+func (s *shipper) writeLate(record any) {
+	line, ok := record.([]byte)
+	if !ok {
+		var err error
+		if line, err = json.Marshal(record); err != nil {
+			s.dropped.Add(1)
+			return
+		}
+		line = append(line, '\n')
+	}
+	s.stderr.Write(line)
+}
+
+// Close ships the queued entries and stops the background goroutine, it waits at most ShutdownTimeout.
+func (s *shipper) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.deadline.Store(time.Now().Add(s.config.ShutdownTimeout).UnixNano())
+	close(s.items)
+	s.mu.Unlock()
+	timer := time.NewTimer(s.config.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+	case <-timer.C:
+	}
+	s.reportDropped()
+	return nil
+}
+
+// remaining returns the time left until the deadline capped at max, false once the deadline has passed.
+func (s *shipper) remaining(max time.Duration) (time.Duration, bool) {
+	deadline := s.deadline.Load()
+	if deadline == 0 {
+		return max, true
+	}
+	left := time.Until(time.Unix(0, deadline))
+	if left <= 0 {
+		return 0, false
+	}
+	return min(left, max), true
+}
+
+// send posts the batch with retries, the failures are reported on stderr since the logger cannot log its own failures.
+// The batch is dropped and counted once the deadline of a flush or Close has passed.
+func (s *shipper) send(batch []any) {
+	if _, ok := s.remaining(0); !ok {
+		s.dropped.Add(uint64(len(batch)))
+		return
+	}
+	body, contentType, err := s.encode(batch)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "logger: encoding %d log entries failed: %v\n", len(batch), err)
+		return
+	}
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		timeout, ok := s.remaining(s.config.Timeout)
+		if !ok {
+			s.dropped.Add(uint64(len(batch)))
+			return
+		}
+		retry, err := s.post(body, contentType, timeout)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= s.config.MaxRetries {
+			fmt.Fprintf(s.stderr, "logger: shipping %d log entries to %s failed: %v\n", len(batch), s.config.Endpoint, err)
+			return
+		}
+		if wait, ok := s.remaining(backoff); ok {
+			time.Sleep(wait)
+		}
+		backoff *= 2
+	}
+}
+
+// post posts the body, it reports whether a failure is worth a retry.
+func (s *shipper) post(body []byte, contentType string, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("collector responded %s", resp.Status)
+}
+
+func (s *shipper) encode(batch []any) ([]byte, string, error) {
+	if s.config.Format == ShipperOTLP {
+		payload := map[string]any{
+			"resourceLogs": []any{map[string]any{
+				"resource": map[string]any{
+					"attributes": []any{otlpKeyValue("service.name", s.appName)},
+				},
+				"scopeLogs": []any{map[string]any{
+					"scope":      map[string]any{"name": otlpScope},
+					"logRecords": batch,
+				}},
+			}},
+		}
+		body, err := json.Marshal(payload)
+		return body, "application/json", err
+	}
+	var buf bytes.Buffer
+	for _, record := range batch {
+		buf.Write(record.([]byte))
+	}
+	return buf.Bytes(), "application/x-ndjson", nil
+}
+
+// httpCore encodes the entries for the shipper, json lines with the encoder or OTLP log records.
+type httpCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	// fields added by With, for the OTLP records
+	fields  []zapcore.Field
+	shipper *shipper
+}
+
+func newHTTPCore(config *HTTPSinkConfig, appName string, encoderConfig zapcore.EncoderConfig, level zapcore.LevelEnabler) (*httpCore, error) {
+	s, err := newShipper(config, appName)
+	if err != nil {
+		return nil, err
+	}
+	return &httpCore{LevelEnabler: level, encoder: zapcore.NewJSONEncoder(encoderConfig), shipper: s}, nil
+}
+
+func (c *httpCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *httpCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// Write queues the entry, the panic and fatal entries are shipped with the queued entries before Write returns,
+// as the process exits right after them.
+func (c *httpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.shipper.config.Format == ShipperOTLP {
+		c.shipper.submit(otlpRecord(entry, append(c.fields[:len(c.fields):len(c.fields)], fields...)))
+	} else {
+		buf, err := c.encoder.EncodeEntry(entry, fields)
+		if err != nil {
+			return err
+		}
+		line := make([]byte, buf.Len())
+		copy(line, buf.Bytes())
+		buf.Free()
+		c.shipper.submit(line)
+	}
+	if entry.Level > zapcore.ErrorLevel {
+		c.shipper.flush()
+	}
+	return nil
+}
+
+func (c *httpCore) Sync() error {
+	c.shipper.flush()
+	return nil
+}
+
+func (c *httpCore) Close() error {
+	return c.shipper.Close()
+}
+
+var otlpSeverities = map[zapcore.Level]int{
+	zapcore.DebugLevel:  5,
+	zapcore.InfoLevel:   9,
+	zapcore.WarnLevel:   13,
+	zapcore.ErrorLevel:  17,
+	zapcore.DPanicLevel: 19,
+	zapcore.PanicLevel:  21,
+	zapcore.FatalLevel:  24,
+}
+
+// otlpRecord returns the OTLP log record of the entry, the traceId and spanId fields of TraceMiddleLayer become its trace context.
+func otlpRecord(entry zapcore.Entry, fields []zapcore.Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	record := map[string]any{
+		"timeUnixNano":         strconv.FormatInt(entry.Time.UnixNano(), 10),
+		"observedTimeUnixNano": strconv.FormatInt(time.Now().UnixNano(), 10),
+		"severityNumber":       otlpSeverities[entry.Level],
+		"severityText":         entry.Level.CapitalString(),
+		"body":                 map[string]any{"stringValue": entry.Message},
+	}
+	if traceID, ok := enc.Fields[traceIDKey].(string); ok {
+		record["traceId"] = traceID
+		delete(enc.Fields, traceIDKey)
+	}
+	if spanID, ok := enc.Fields[spanIDKey].(string); ok {
+		record["spanId"] = spanID
+		delete(enc.Fields, spanIDKey)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attributes := make([]any, 0, len(keys)+4)
+	for _, key := range keys {
+		attributes = append(attributes, otlpKeyValue(key, enc.Fields[key]))
+	}
+	if entry.Caller.Defined {
+		attributes = append(attributes,
+			otlpKeyValue("code.filepath", entry.Caller.File),
+			otlpKeyValue("code.lineno", entry.Caller.Line),
+			otlpKeyValue("code.function", entry.Caller.Function))
+	}
+	if entry.Stack != "" {
+		attributes = append(attributes, otlpKeyValue("exception.stacktrace", entry.Stack))
+	}
+	record["attributes"] = attributes
+	return record
+}
+
+func otlpKeyValue(key string, value any) map[string]any {
+	return map[string]any{"key": key, "value": otlpValue(value)}
+}
+
+// otlpValue returns the OTLP AnyValue of a value of the map encoder.
+func otlpValue(value any) map[string]any {
+	switch v := value.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return map[string]any{"intValue": fmt.Sprint(v)}
+	case float32, float64:
+		return map[string]any{"doubleValue": v}
+	case []byte:
+		return map[string]any{"bytesValue": v}
+	case time.Time:
+		return map[string]any{"stringValue": v.Format(time.RFC3339Nano)}
+	case time.Duration:
+		return map[string]any{"stringValue": v.String()}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]any, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, otlpKeyValue(key, v[key]))
+		}
+		return map[string]any{"kvlistValue": map[string]any{"values": values}}
+	case []any:
+		values := make([]any, 0, len(v))
+		for _, item := range v {
+			values = append(values, otlpValue(item))
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": values}}
+	case nil:
+		return map[string]any{}
+	}
+	if bytes, err := json.Marshal(value); err == nil {
+		return map[string]any{"stringValue": string(bytes)}
+	}
+	return map[string]any{"stringValue": fmt.Sprint(value)}
+}
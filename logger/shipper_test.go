@@ -0,0 +1,279 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// collector is a fake log collector, it records the request bodies and responds with the queued statuses, then 200.
+type collector struct {
+	mu       sync.Mutex
+	bodies   []string
+	statuses []int
+	// release blocks the requests until it is closed, if not nil
+	release  chan struct{}
+	received chan struct{}
+}
+
+func newCollector(t *testing.T, c *collector) *httptest.Server {
+	c.received = make(chan struct{}, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, string(body))
+		status := http.StatusOK
+		if len(c.statuses) > 0 {
+			status, c.statuses = c.statuses[0], c.statuses[1:]
+		}
+		release := c.release
+		c.mu.Unlock()
+		c.received <- struct{}{}
+		if release != nil {
+			<-release
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (c *collector) requests() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.bodies...)
+}
+
+func (c *collector) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("collector received %d requests, want %d", len(c.requests()), n)
+		}
+	}
+}
+
+// syncBuffer is a buffer safe for the concurrent writes of the shipper.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestShipper(t *testing.T, config HTTPSinkConfig) (*shipper, *syncBuffer) {
+	t.Helper()
+	s, err := newShipper(&config, "test-app")
+	if err != nil {
+		t.Fatalf("newShipper() = %v", err)
+	}
+	stderr := &syncBuffer{}
+	s.stderr = stderr
+	t.Cleanup(func() { s.Close() })
+	return s, stderr
+}
+
+func line(s string) []byte {
+	return []byte(s + "\n")
+}
+
+func TestShipperBatchSize(t *testing.T) {
+	c := &collector{}
+	server := newCollector(t, c)
+	s, _ := newTestShipper(t, HTTPSinkConfig{Endpoint: server.URL, BatchSize: 2, FlushInterval: time.Hour})
+
+	for _, entry := range []string{"a", "b", "c", "d", "e"} {
+		s.submit(line(entry))
+	}
+	c.wait(t, 2)
+	// the last entry is shipped on Close
+	s.Close()
+	c.wait(t, 1)
+	if got, want := c.requests(), []string{"a\nb\n", "c\nd\n", "e\n"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("requests = %q, want %q", got, want)
+	}
+}
+
+func TestShipperFlushInterval(t *testing.T) {
+	c := &collector{}
+	server := newCollector(t, c)
+	s, _ := newTestShipper(t, HTTPSinkConfig{Endpoint: server.URL, FlushInterval: 20 * time.Millisecond})
+
+	s.submit(line("a"))
+	c.wait(t, 1)
+	if got := c.requests(); len(got) != 1 || got[0] != "a\n" {
+		t.Fatalf("requests = %q, want [a]", got)
+	}
+}
+
+func TestShipperRetry(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusServiceUnavailable}}
+	server := newCollector(t, c)
+	s, stderr := newTestShipper(t, HTTPSinkConfig{Endpoint: server.URL, FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+
+	s.submit(line("a"))
+	s.flush()
+	if got := c.requests(); len(got) != 2 || got[0] != "a\n" || got[1] != "a\n" {
+		t.Fatalf("requests = %q, want the batch retried once", got)
+	}
+	if stderr.String() != "" {
+		t.Fatalf("stderr = %q, want empty", stderr.String())
+	}
+}
+
+func TestShipperDropsWhenFull(t *testing.T) {
+	c := &collector{release: make(chan struct{})}
+	server := newCollector(t, c)
+	s, stderr := newTestShipper(t, HTTPSinkConfig{Endpoint: server.URL, BatchSize: 1, BufferSize: 2})
+
+	s.submit(line("a"))
+	// the shipper is blocked on the first request, the buffer holds two entries
+	c.wait(t, 1)
+	for _, entry := range []string{"b", "c", "d", "e"} {
+		s.submit(line(entry))
+	}
+	if got := s.dropped.Load(); got != 2 {
+		t.Fatalf("dropped = %d, want 2", got)
+	}
+	close(c.release)
+	s.Close()
+	if got := c.requests(); len(got) != 3 {
+		t.Fatalf("requests = %q, want the 3 entries of the buffer", got)
+	}
+	if !strings.Contains(stderr.String(), "dropped 2 log entries") {
+		t.Fatalf("stderr = %q, want the dropped count", stderr.String())
+	}
+}
+
+func TestShipperFlushDeadline(t *testing.T) {
+	c := &collector{statuses: make([]int, 1000)}
+	for i := range c.statuses {
+		c.statuses[i] = http.StatusServiceUnavailable
+	}
+	server := newCollector(t, c)
+	s, stderr := newTestShipper(t, HTTPSinkConfig{
+		Endpoint: server.URL, BatchSize: 1, FlushInterval: time.Hour,
+		MaxRetries: 100, RetryBackoff: 10 * time.Millisecond, ShutdownTimeout: 100 * time.Millisecond,
+	})
+
+	for _, entry := range []string{"a", "b", "c"} {
+		s.submit(line(entry))
+	}
+	start := time.Now()
+	s.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Close() took %v, want at most about the ShutdownTimeout", elapsed)
+	}
+	// the shipper may still be finishing the request in flight when Close returns
+	<-s.done
+	if !strings.Contains(stderr.String(), "dropped 3 log entries") {
+		t.Fatalf("stderr = %q, want the 3 entries dropped", stderr.String())
+	}
+}
+
+func TestShipperAfterClose(t *testing.T) {
+	c := &collector{}
+	server := newCollector(t, c)
+	s, stderr := newTestShipper(t, HTTPSinkConfig{Endpoint: server.URL})
+
+	s.Close()
+	s.submit(line("late"))
+	s.flush()
+	if got := c.requests(); len(got) != 0 {
+		t.Fatalf("requests = %q, want none after Close", got)
+	}
+	if stderr.String() != "late\n" {
+		t.Fatalf("stderr = %q, want the late entry", stderr.String())
+	}
+}
+
+func TestHTTPCoreOTLP(t *testing.T) {
+	c := &collector{}
+	server := newCollector(t, c)
+	core, err := newHTTPCore(&HTTPSinkConfig{Endpoint: server.URL, Format: ShipperOTLP}, "test-app", zap.NewProductionEncoderConfig(), zapcore.DebugLevel)
+	if err != nil {
+		t.Fatalf("newHTTPCore() = %v", err)
+	}
+	zap.New(core).With(zap.String("requestId", "r1")).Warn("hello", zap.String(traceIDKey, "0af7651916cd43dd8448eb211c80319c"), zap.Int("count", 2))
+	core.Close()
+
+	requests := c.requests()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %q, want 1", requests)
+	}
+	var payload struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				LogRecords []struct {
+					SeverityNumber int               `json:"severityNumber"`
+					SeverityText   string            `json:"severityText"`
+					Body           map[string]string `json:"body"`
+					TraceID        string            `json:"traceId"`
+					Attributes     []map[string]any  `json:"attributes"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	if err := json.Unmarshal([]byte(requests[0]), &payload); err != nil {
+		t.Fatalf("invalid otlp payload %s : %v", requests[0], err)
+	}
+	resource := payload.ResourceLogs[0]
+	if got := resource.Resource.Attributes[0]; got["key"] != "service.name" || got["value"].(map[string]any)["stringValue"] != "test-app" {
+		t.Fatalf("resource attributes = %v", resource.Resource.Attributes)
+	}
+	if resource.ScopeLogs[0].Scope.Name != otlpScope {
+		t.Fatalf("scope = %q, want %q", resource.ScopeLogs[0].Scope.Name, otlpScope)
+	}
+	record := resource.ScopeLogs[0].LogRecords[0]
+	if record.SeverityNumber != 13 || record.SeverityText != "WARN" || record.Body["stringValue"] != "hello" || record.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+		t.Fatalf("record = %+v", record)
+	}
+	attributes := map[string]any{}
+	for _, attribute := range record.Attributes {
+		attributes[attribute["key"].(string)] = attribute["value"]
+	}
+	want := map[string]any{
+		"count":     map[string]any{"intValue": "2"},
+		"requestId": map[string]any{"stringValue": "r1"},
+	}
+	for key, value := range want {
+		if got, _ := json.Marshal(attributes[key]); string(got) != mustJSON(value) {
+			t.Fatalf("attribute %s = %s, want %s", key, got, mustJSON(value))
+		}
+	}
+	if _, ok := attributes[traceIDKey]; ok {
+		t.Fatalf("attributes = %v, want the traceId moved to the record", attributes)
+	}
+}
+
+func mustJSON(value any) string {
+	bytes, _ := json.Marshal(value)
+	return string(bytes)
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type SinkType string
+
+const (
+	SinkStdout SinkType = "stdout"
+	SinkStderr SinkType = "stderr"
+	SinkFile   SinkType = "file"
+	SinkSyslog SinkType = "syslog"
+	SinkHTTP   SinkType = "http"
+)
+
+// SinkConfig : output of the logger, the logger writes to stderr if no sink is configured
+// Type : one of stdout, stderr, file, syslog, http
+// Level : minimum level written to the sink, e.g. error for a separate error file, default every level enabled on the logger
+// File : rotation of the file, for file sinks
+// Syslog : syslog connection, for syslog sinks
+// HTTP : collector and batching, for http sinks, they always ship in the background
+// Async : writes to the sink in the background through a bounded buffer
+type SinkConfig struct {
+	Type   SinkType         `yaml:"Type" json:"Type" name:"Type" type:"choice" description:"Sink Type" choices:"stdout,stderr,file,syslog,http" required:"true"`
+	Level  LogLevel         `yaml:"Level" json:"Level" name:"Level" type:"choice" description:"Minimum Level of the Sink" choices:"debug,info,warn,error,panic,fatal"`
+	File   FileSinkConfig   `yaml:"File" json:"File"`
+	Syslog SyslogSinkConfig `yaml:"Syslog" json:"Syslog"`
+	HTTP   HTTPSinkConfig   `yaml:"HTTP" json:"HTTP"`
+	Async  AsyncSinkConfig  `yaml:"Async" json:"Async"`
+}
+
+// FileSinkConfig : rotating log file
+// Path : path of the log file, rotated files are kept next to it with a timestamp in their name
+// MaxSizeMB : size at which the file is rotated, default 100
+// MaxAgeDays : rotated files older than this are removed, default 0 keeps them
+// MaxBackups : number of rotated files kept, default 0 keeps all of them
+// Compress : compresses the rotated files with gzip
+// LocalTime : uses the local time in the names of the rotated files, default UTC
+type FileSinkConfig struct {
+	Path       string `yaml:"Path" json:"Path" name:"Path" type:"string" description:"Log File Path"`
+	MaxSizeMB  int    `yaml:"MaxSizeMB" json:"MaxSizeMB" name:"MaxSizeMB" type:"int" description:"Rotation Size in MB" default:"100"`
+	MaxAgeDays int    `yaml:"MaxAgeDays" json:"MaxAgeDays" name:"MaxAgeDays" type:"int" description:"Retention of Rotated Files in Days"`
+	MaxBackups int    `yaml:"MaxBackups" json:"MaxBackups" name:"MaxBackups" type:"int" description:"Number of Rotated Files Kept"`
+	Compress   bool   `yaml:"Compress" json:"Compress" name:"Compress" type:"bool" description:"Compress Rotated Files"`
+	LocalTime  bool   `yaml:"LocalTime" json:"LocalTime" name:"LocalTime" type:"bool" description:"Local Time in Rotated File Names"`
+}
+
+// SyslogSinkConfig : syslog connection
+// Network : network of the syslog server, tcp, udp or empty for the local syslog daemon
+// Address : address of the syslog server, e.g. localhost:514
+// Tag : tag of the messages, default the app name
+// Facility : syslog facility, default local0
+type SyslogSinkConfig struct {
+	Network  string `yaml:"Network" json:"Network" name:"Network" type:"choice" description:"Syslog Network" choices:"tcp,udp,unix,unixgram"`
+	Address  string `yaml:"Address" json:"Address" name:"Address" type:"string" description:"Syslog Address"`
+	Tag      string `yaml:"Tag" json:"Tag" name:"Tag" type:"string" description:"Syslog Tag"`
+	Facility string `yaml:"Facility" json:"Facility" name:"Facility" type:"choice" description:"Syslog Facility" choices:"kern,user,mail,daemon,auth,syslog,lpr,news,uucp,cron,authpriv,ftp,local0,local1,local2,local3,local4,local5,local6,local7" default:"local0"`
+}
+
+// AsyncSinkConfig : background writes through a bounded buffer, so that slow sinks do not block the logging goroutines
+// Enabled : enables the background writes
+// BufferSize : number of entries buffered, default 1024
+// OnFull : drop or block when the buffer is full, default drop. dropped entries are counted and reported on stderr
+type AsyncSinkConfig struct {
+	Enabled    bool   `yaml:"Enabled" json:"Enabled" name:"Enabled" type:"bool" description:"Enable Async Writes"`
+	BufferSize int    `yaml:"BufferSize" json:"BufferSize" name:"BufferSize" type:"int" description:"Buffered Entries" default:"1024"`
+	OnFull     string `yaml:"OnFull" json:"OnFull" name:"OnFull" type:"choice" description:"Behaviour on Full Buffer" choices:"drop,block" default:"drop"`
+}
+
+// sinkLevel returns the minimum level of the sink.
+func (s *SinkConfig) sinkLevel() (zapcore.Level, error) {
+	if s.Level == "" {
+		return zapcore.DebugLevel, nil
+	}
+	level, found := logLevelToZapLevelMap[s.Level]
+	if !found {
+		return level, fmt.Errorf("invalid log level %q for %s sink", s.Level, s.Type)
+	}
+	return level, nil
+}
+
+// buildSinks builds a core per sink, the returned closers release the files, connections and goroutines of the sinks.
+// The http sinks encode json with the encoder config, the other sinks use the encoder.
+func buildSinks(sinks []SinkConfig, encoder zapcore.Encoder, encoderConfig zapcore.EncoderConfig, appName string) ([]zapcore.Core, []io.Closer, error) {
+	var cores []zapcore.Core
+	var closers []io.Closer
+	for i := range sinks {
+		core, closer, err := buildSink(&sinks[i], encoder.Clone(), encoderConfig, appName)
+		if err != nil {
+			for _, c := range closers {
+				c.Close()
+			}
+			return nil, nil, err
+		}
+		cores = append(cores, core)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+	return cores, closers, nil
+}
+
+func buildSink(sink *SinkConfig, encoder zapcore.Encoder, encoderConfig zapcore.EncoderConfig, appName string) (zapcore.Core, io.Closer, error) {
+	level, err := sink.sinkLevel()
+	if err != nil {
+		return nil, nil, err
+	}
+	if sink.Type == SinkHTTP {
+		core, err := newHTTPCore(&sink.HTTP, appName, encoderConfig, level)
+		if err != nil {
+			return nil, nil, err
+		}
+		return core, core, nil
+	}
+
+	var async *asyncWriter
+	if sink.Async.Enabled {
+		async = newAsyncWriter(&sink.Async)
+	}
+
+	var ws zapcore.WriteSyncer
+	var closer io.Closer
+	switch sink.Type {
+	case SinkStdout:
+		ws = zapcore.Lock(os.Stdout)
+	case SinkStderr, "":
+		ws = zapcore.Lock(os.Stderr)
+	case SinkFile:
+		if sink.File.Path == "" {
+			return nil, nil, errors.New("file sink requires File.Path")
+		}
+		maxSize := sink.File.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = 100
+		}
+		file := &lumberjack.Logger{
+			Filename:   sink.File.Path,
+			MaxSize:    maxSize,
+			MaxAge:     sink.File.MaxAgeDays,
+			MaxBackups: sink.File.MaxBackups,
+			Compress:   sink.File.Compress,
+			LocalTime:  sink.File.LocalTime,
+		}
+		ws, closer = zapcore.AddSync(file), file
+	case SinkSyslog:
+		core, err := newSyslogCore(&sink.Syslog, appName, encoder, level, async)
+		if err != nil {
+			if async != nil {
+				async.Close()
+			}
+			return nil, nil, err
+		}
+		return core, core, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid sink type %q", sink.Type)
+	}
+
+	if async != nil {
+		ws = &asyncWriteSyncer{async: async, ws: ws, closer: closer}
+		closer = ws.(io.Closer)
+	}
+	return zapcore.NewCore(encoder, ws, level), closer, nil
+}
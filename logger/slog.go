@@ -0,0 +1,311 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// recordLogger is implemented by the loggers writing the entries of the slog records with the caller of the record.
+type recordLogger interface {
+	enabledLevel(level zapcore.Level) bool
+	logRecord(ctx context.Context, level zapcore.Level, msg string, fields *Fields, pc uintptr)
+}
+
+// slogHandler is a slog.Handler writing through a goutils logger, so that its middle-layers, sinks and levels apply.
+type slogHandler struct {
+	logger Logger
+	// fields added by WithAttrs, their keys are prefixed by the groups
+	fields []zap.Field
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler writing the records through l, a nil l writes through the global logger, following InitiateLogger.
+// The records are written with their attributes as fields, the attributes of the groups are prefixed by the group names, e.g. request.method.
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(nil)))
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+func (h *slogHandler) target() Logger {
+	if h.logger == nil {
+		return global()
+	}
+	return h.logger
+}
+
+// slogToZapLevel maps the slog levels to the zap levels, the levels between two slog levels round down.
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func zapToSlogLevel(level zapcore.Level) slog.Level {
+	switch level {
+	case zapcore.DebugLevel:
+		return slog.LevelDebug
+	case zapcore.InfoLevel:
+		return slog.LevelInfo
+	case zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if l, ok := h.target().(recordLogger); ok {
+		return l.enabledLevel(slogToZapLevel(level))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := &Fields{fields: make([]zap.Field, 0, len(h.fields)+record.NumAttrs())}
+	fields.fields = append(fields.fields, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields.fields = appendAttr(fields.fields, h.prefix, attr)
+		return true
+	})
+	level := slogToZapLevel(record.Level)
+	l := h.target()
+	if l, ok := l.(recordLogger); ok {
+		l.logRecord(ctx, level, record.Message, fields, record.PC)
+		return nil
+	}
+	switch level {
+	case zapcore.DebugLevel:
+		l.Debugf(ctx, record.Message, fields)
+	case zapcore.InfoLevel:
+		l.Infof(ctx, record.Message, fields)
+	case zapcore.WarnLevel:
+		l.Warnf(ctx, record.Message, fields)
+	default:
+		l.Errorf(ctx, record.Message, fields)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]zap.Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.prefix, attr)
+	}
+	return &slogHandler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// appendAttr appends the attribute as fields, the groups are flattened with their names as key prefix.
+func appendAttr(fields []zap.Field, prefix string, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		// the attributes of a group without a name are inlined
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, groupAttr)
+		}
+		return fields
+	case slog.KindTime:
+		return append(fields, zap.Time(prefix+attr.Key, attr.Value.Time()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(prefix+attr.Key, attr.Value.Duration()))
+	}
+	return append(fields, zap.Any(prefix+attr.Key, attr.Value.Any()))
+}
+
+func (l *logger) enabledLevel(level zapcore.Level) bool {
+	return l.levels.enabled(level)
+}
+
+// logRecord writes the entry with the caller of pc, the caller of the slog record, instead of the caller of the logger.
+func (l *logger) logRecord(ctx context.Context, level zapcore.Level, msg string, fs *Fields, pc uintptr) {
+	if !l.levels.enabled(level) {
+		return
+	}
+	state := l.state.Load()
+	if !state.allow(level, msg) {
+		return
+	}
+	_, msg, fields := l.executeMiddleLayers(ctx, msg, l.entryFields(ctx, state, fs))
+	ce := state.zapLogger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		ce.Caller = zapcore.EntryCaller{Defined: true, PC: pc, File: frame.File, Line: frame.Line, Function: frame.Function}
+	}
+	ce.Write(fields.fields...)
+}
+
+// slogLogger is a Logger writing through a slog.Handler, the reverse of NewSlogHandler.
+type slogLogger struct {
+	handler      slog.Handler
+	middleLayers []MiddleLayer
+	fields       []zap.Field
+	// global is set when the logger replaces the global logger, its caller is the caller of the package functions
+	global bool
+}
+
+// NewSlogLogger returns a Logger writing the entries as records of the handler, e.g. to pass slog.Default().Handler() where a Logger is expected.
+// The middle-layers run before the records are handled, the fields become attributes.
+// The levels, sinks and configuration belong to the handler, so ChangeConfig and SetLevels return an error.
+func NewSlogLogger(handler slog.Handler, middleLayers ...MiddleLayer) Logger {
+	return &slogLogger{handler: handler, middleLayers: middleLayers}
+}
+
+var errSlogLogger = errors.New("the slog logger is configured by its slog handler")
+
+// log writes the record with the caller of the Logger method, a panic level entry panics after it is handled like zap.
+func (s *slogLogger) log(ctx context.Context, level zapcore.Level, msg string, fs *Fields) {
+	slogLevel := zapToSlogLevel(level)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !s.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	fields := &Fields{fields: make([]zap.Field, 0, len(s.fields)+8)}
+	fields.fields = append(fields.fields, s.fields...)
+	fields.fields = append(fields.fields, ContextFields(ctx)...)
+	if fs != nil {
+		fields.fields = append(fields.fields, fs.fields...)
+	}
+	for _, layer := range s.middleLayers {
+		ctx, msg, fields = layer(ctx, msg, fields)
+	}
+	var pcs [1]uintptr
+	// skips runtime.Callers, log and the Logger method, and the package function for the global logger
+	skip := 3
+	if s.global {
+		skip++
+	}
+	runtime.Callers(skip, pcs[:])
+	record := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields.fields {
+		field.AddTo(encoder)
+		record.AddAttrs(slog.Any(field.Key, encoder.Fields[field.Key]))
+	}
+	s.handler.Handle(ctx, record)
+	if level == zapcore.PanicLevel {
+		panic(msg)
+	}
+}
+
+func (s *slogLogger) Info(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.InfoLevel, fmt.Sprintf(format, a...), nil)
+}
+
+func (s *slogLogger) Error(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.ErrorLevel, fmt.Sprintf(format, a...), nil)
+}
+
+func (s *slogLogger) Warn(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.WarnLevel, fmt.Sprintf(format, a...), nil)
+}
+
+func (s *slogLogger) Debug(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.DebugLevel, fmt.Sprintf(format, a...), nil)
+}
+
+func (s *slogLogger) Panic(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.PanicLevel, fmt.Sprintf(format, a...), nil)
+}
+
+func (s *slogLogger) Fatal(ctx context.Context, format string, a ...any) {
+	s.log(ctx, zapcore.FatalLevel, fmt.Sprintf(format, a...), nil)
+	os.Exit(1)
+}
+
+func (s *slogLogger) Infof(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.InfoLevel, msg, fields)
+}
+
+func (s *slogLogger) Debugf(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.DebugLevel, msg, fields)
+}
+
+func (s *slogLogger) Errorf(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.ErrorLevel, msg, fields)
+}
+
+func (s *slogLogger) Warnf(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.WarnLevel, msg, fields)
+}
+
+func (s *slogLogger) Panicf(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.PanicLevel, msg, fields)
+}
+
+func (s *slogLogger) Fatalf(ctx context.Context, msg string, fields *Fields) {
+	s.log(ctx, zapcore.FatalLevel, msg, fields)
+	os.Exit(1)
+}
+
+func (s *slogLogger) With(fields ...*Field) Logger {
+	child := &slogLogger{handler: s.handler, middleLayers: s.middleLayers}
+	child.fields = make([]zap.Field, 0, len(s.fields)+len(fields))
+	child.fields = append(child.fields, s.fields...)
+	for _, field := range fields {
+		child.fields = append(child.fields, zap.Any(field.Key, field.Value))
+	}
+	return child
+}
+
+func (s *slogLogger) AddMiddleLayers(middlelayers ...MiddleLayer) {
+	s.middleLayers = append(s.middleLayers, middlelayers...)
+}
+
+func (s *slogLogger) ReplaceMiddleLayers(middlelayers ...MiddleLayer) {
+	s.middleLayers = middlelayers
+}
+
+func (s *slogLogger) ChangeConfig(config *Config) error {
+	return errSlogLogger
+}
+
+func (s *slogLogger) Levels() LevelConfig {
+	return LevelConfig{}
+}
+
+func (s *slogLogger) SetLevels(config LevelConfig, ttl time.Duration) error {
+	return errSlogLogger
+}
+
+func (s *slogLogger) Sync() error {
+	return nil
+}
@@ -0,0 +1,105 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL, "daemon": syslog.LOG_DAEMON,
+	"auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG, "lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS,
+	"uucp": syslog.LOG_UUCP, "cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// syslogCore writes the encoded entries to syslog with the severity of their level.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+	async   *asyncWriter
+}
+
+func newSyslogCore(config *SyslogSinkConfig, appName string, encoder zapcore.Encoder, level zapcore.LevelEnabler, async *asyncWriter) (*syslogCore, error) {
+	facility := syslog.LOG_LOCAL0
+	if config.Facility != "" {
+		var found bool
+		if facility, found = syslogFacilities[config.Facility]; !found {
+			return nil, fmt.Errorf("invalid syslog facility %q", config.Facility)
+		}
+	}
+	tag := config.Tag
+	if tag == "" {
+		tag = appName
+	}
+	writer, err := syslog.Dial(config.Network, config.Address, facility|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogCore{LevelEnabler: level, encoder: encoder, writer: writer, async: async}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+	return &clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	buf.Free()
+	write := func() error {
+		switch entry.Level {
+		case zapcore.DebugLevel:
+			return c.writer.Debug(msg)
+		case zapcore.InfoLevel:
+			return c.writer.Info(msg)
+		case zapcore.WarnLevel:
+			return c.writer.Warning(msg)
+		case zapcore.ErrorLevel:
+			return c.writer.Err(msg)
+		case zapcore.DPanicLevel, zapcore.PanicLevel:
+			return c.writer.Crit(msg)
+		default:
+			return c.writer.Emerg(msg)
+		}
+	}
+	if c.async != nil {
+		return c.async.submit(write)
+	}
+	return write()
+}
+
+func (c *syslogCore) Sync() error {
+	if c.async != nil {
+		c.async.flush()
+	}
+	return nil
+}
+
+func (c *syslogCore) Close() error {
+	if c.async != nil {
+		c.async.Close()
+	}
+	return c.writer.Close()
+}
@@ -0,0 +1,22 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogCore is not supported on this platform.
+type syslogCore struct {
+	zapcore.Core
+}
+
+func newSyslogCore(config *SyslogSinkConfig, appName string, encoder zapcore.Encoder, level zapcore.LevelEnabler, async *asyncWriter) (*syslogCore, error) {
+	return nil, errors.New("syslog sink is not supported on this platform")
+}
+
+func (c *syslogCore) Close() error {
+	return nil
+}
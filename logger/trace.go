@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContextPropagator reads and writes the W3C traceparent and tracestate headers
+var traceContextPropagator = propagation.TraceContext{}
+
+// TraceMiddleLayer adds the trace and span IDs of the OpenTelemetry span context in ctx, so that the logs can be joined with the traces.
+func TraceMiddleLayer(ctx context.Context, msg string, fields *Fields) (context.Context, string, *Fields) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ctx, msg, fields
+	}
+	fields.AddField(traceIDKey, spanContext.TraceID().String())
+	fields.AddField(spanIDKey, spanContext.SpanID().String())
+	return ctx, msg, fields
+}
+
+// ExtractTraceContext returns ctx with the span context of the W3C traceparent header.
+// ctx is returned as it is if it already has a span, e.g. started by an OpenTelemetry tracer, or if the header is missing or invalid,
+// so that the sampler of the tracer decides on the root spans. The request id correlates the logs of the requests without a trace.
+func ExtractTraceContext(ctx context.Context, header http.Header) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return traceContextPropagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectTraceContext sets the W3C traceparent header of the span context in ctx, e.g. on outgoing requests.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	traceContextPropagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -37,9 +38,26 @@ func NewField(key string, value any) *Field {
 type MiddleLayer func(ctx context.Context, msg string, fields *Fields) (context.Context, string, *Fields)
 
 // Build : if prod it will set to prod else dev
+// Encoding : console, json or logfmt, default json for prod builds and the schemas, console for dev builds
+// Schema : field names and severities of a log platform, ecs, gcp or datadog, default timeStamp, level, msg, caller and App
+// Sinks : outputs of the logger with their own minimum levels, e.g. everything to stdout and errors to a rotating file, default stderr
+// Sampling : zap sampling by level and message, default 100 then every 100th per second for prod builds, negative Initial disables it
+// RateLimit : limit by level and message template, so that the same log line with different arguments is limited as one, e.g. first 10 per second then 1 in 1000
+// StacktraceLevel : minimum level of the entries with the stack trace of the log call and of the errors wrapped by customerrors.Wrap, default error, none disables them
+// Redaction : masking of sensitive field keys, emails, mobiles, card numbers and bearer tokens in the messages and fields
 type Config struct {
-	AppName    string   `yaml:"AppName" json:"AppName" name:"AppName" type:"string" description:"Application Name" required:"true"`
-	Build      Build    `yaml:"Build" json:"Build" name:"Build" type:"choice" description:"Build Type" choices:"prod,dev"`
-	Level      LogLevel `yaml:"Level" json:"Level" name:"Level" type:"choice" description:"Log Level" choices:"debug,info,warn,error,panic,fatal"`
-	skipLevels int
+	AppName         string          `yaml:"AppName" json:"AppName" name:"AppName" type:"string" description:"Application Name" required:"true"`
+	Build           Build           `yaml:"Build" json:"Build" name:"Build" type:"choice" description:"Build Type" choices:"prod,dev"`
+	Level           LogLevel        `yaml:"Level" json:"Level" name:"Level" type:"choice" description:"Log Level" choices:"debug,info,warn,error,panic,fatal"`
+	Encoding        Encoding        `yaml:"Encoding" json:"Encoding" name:"Encoding" type:"choice" description:"Log Encoding" choices:"console,json,logfmt"`
+	Schema          Schema          `yaml:"Schema" json:"Schema" name:"Schema" type:"choice" description:"Field Names of a Log Platform" choices:"ecs,gcp,datadog"`
+	Sinks           []SinkConfig    `yaml:"Sinks" json:"Sinks" name:"Sinks" description:"Outputs of the Logger, default stderr"`
+	Sampling        SamplingConfig  `yaml:"Sampling" json:"Sampling" name:"Sampling" description:"Sampling by Level and Message"`
+	RateLimit       SamplingConfig  `yaml:"RateLimit" json:"RateLimit" name:"RateLimit" description:"Rate Limit by Level and Message Template"`
+	StacktraceLevel LogLevel        `yaml:"StacktraceLevel" json:"StacktraceLevel" name:"StacktraceLevel" type:"choice" description:"Minimum Level of the Stack Traces" choices:"debug,info,warn,error,panic,fatal,none" default:"error"`
+	Redaction       RedactionConfig `yaml:"Redaction" json:"Redaction" name:"Redaction" description:"Masking of Personal Data and Secrets"`
+	// SuppressedSummaryInterval : interval of the warning summarising the entries dropped by the sampling and the rate limit, default 1m
+	SuppressedSummaryInterval time.Duration `yaml:"SuppressedSummaryInterval" json:"SuppressedSummaryInterval" name:"SuppressedSummaryInterval" type:"duration" description:"Interval of the Suppressed Entries Summary" default:"1m"`
+	// global is set for the global logger, its caller is the caller of the package functions
+	global bool
 }
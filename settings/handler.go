@@ -0,0 +1,112 @@
+package settings
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gofreego/goutils/api/response"
+	"github.com/gofreego/goutils/constants"
+	"github.com/gofreego/goutils/customerrors"
+	"github.com/gofreego/goutils/logger"
+)
+
+// Authorizer authorizes the requests to the admin handler, it returns a *customerrors.Error to reject the request.
+type Authorizer func(r *http.Request) error
+
+// TokenAuthorizer accepts the requests with the header "Authorization: Bearer <token>".
+func TokenAuthorizer(token string) Authorizer {
+	return func(r *http.Request) error {
+		bearer, ok := strings.CutPrefix(r.Header.Get(constants.HEADER_AUTHORIZATION), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			return customerrors.ERROR_UNAUTHORISED
+		}
+		return nil
+	}
+}
+
+// PermissionAuthorizer accepts the requests whose x-permissions header, set by the gateway after authentication, contains the permission.
+func PermissionAuthorizer(permission string) Authorizer {
+	return func(r *http.Request) error {
+		for _, p := range strings.Split(r.Header.Get(constants.PERMISSIONS), ",") {
+			if strings.TrimSpace(p) == permission {
+				return nil
+			}
+		}
+		return customerrors.ERROR_PERMISSION_DENIED
+	}
+}
+
+// Handler returns the admin http handler of the store, mounted at basePath.
+// GET basePath : lists every registered key with its current and default value
+// GET basePath/{name} : returns the key
+// PUT basePath/{name} : sets the value of the key, the body is the json value
+// DELETE basePath/{name} : resets the key to its default value
+// Every request is rejected unless authorize accepts it.
+// e.g. mux.Handle("/admin/settings/", store.Handler("/admin/settings", settings.TokenAuthorizer(token)))
+func (s *Store) Handler(basePath string, authorize Authorizer) http.Handler {
+	basePath = strings.TrimSuffix(basePath, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Header().Set("Content-Type", "application/json")
+		if authorize == nil {
+			response.WriteErrorV2(ctx, w, customerrors.ERROR_UNAUTHORISED)
+			return
+		}
+		if err := authorize(r); err != nil {
+			response.WriteErrorV2(ctx, w, err)
+			return
+		}
+
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, basePath), "/")
+		if name == "" {
+			if r.Method != http.MethodGet {
+				response.WriteErrorV2(ctx, w, customerrors.New(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+				return
+			}
+			response.WriteSuccessV2(ctx, w, s.List())
+			return
+		}
+		key, ok := getSetting(name)
+		if !ok {
+			response.WriteErrorV2(ctx, w, customerrors.New(http.StatusNotFound, "setting %s not found", name))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var raw any
+			if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+				response.WriteErrorV2(ctx, w, customerrors.BAD_REQUEST_ERROR("invalid json value, Err: %s", err.Error()))
+				return
+			}
+			value, err := key.decode(raw)
+			if err != nil {
+				response.WriteErrorV2(ctx, w, customerrors.BAD_REQUEST_ERROR("invalid value for setting %s, Err: %s", name, err.Error()))
+				return
+			}
+			if err := s.set(ctx, name, value); err != nil {
+				response.WriteErrorV2(ctx, w, err)
+				return
+			}
+			logger.Info(ctx, "setting %s updated", name)
+		case http.MethodDelete:
+			if err := s.Reset(ctx, name); err != nil {
+				response.WriteErrorV2(ctx, w, err)
+				return
+			}
+			logger.Info(ctx, "setting %s reset to default", name)
+		default:
+			response.WriteErrorV2(ctx, w, customerrors.New(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+			return
+		}
+		for _, setting := range s.List() {
+			if setting.Name == name {
+				response.WriteSuccessV2(ctx, w, setting)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// setting is the untyped view of a Key, used by the store and the admin handler.
+type setting interface {
+	Name() string
+	Description() string
+	defaultValue() any
+	// decode converts the raw value from the config reader into the type of the key.
+	decode(raw any) (any, error)
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]setting{}
+)
+
+// Key is a typed setting with a default value.
+// Keys are declared once, usually as package variables, and read from a Store.
+// e.g. var MaintenanceMode = settings.NewKey("maintenanceMode", false, "rejects every request with 503")
+type Key[T any] struct {
+	name        string
+	def         T
+	description string
+}
+
+// NewKey creates and registers a setting key, the name is the key of the value in the settings document.
+// It panics if a key with the same name is already registered.
+func NewKey[T any](name string, def T, description string) *Key[T] {
+	key := &Key[T]{name: name, def: def, description: description}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("settings: key %s is already registered", name))
+	}
+	registry[name] = key
+	return key
+}
+
+// Name returns the name of the key.
+func (k *Key[T]) Name() string {
+	return k.name
+}
+
+// Description returns the description of the key.
+func (k *Key[T]) Description() string {
+	return k.description
+}
+
+// Default returns the default value of the key.
+func (k *Key[T]) Default() T {
+	return k.def
+}
+
+// Get returns the current value of the key from the store.
+// It returns the default value if the store is nil, the value is not set or can not be decoded into T.
+func (k *Key[T]) Get(s *Store) T {
+	if s == nil {
+		return k.def
+	}
+	value, ok := s.value(k)
+	if !ok {
+		return k.def
+	}
+	return value.(T)
+}
+
+// Set updates the value of the key in the config reader of the store.
+func (k *Key[T]) Set(ctx context.Context, s *Store, value T) error {
+	return s.set(ctx, k.name, value)
+}
+
+func (k *Key[T]) defaultValue() any {
+	return k.def
+}
+
+func (k *Key[T]) decode(raw any) (any, error) {
+	bytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var value T
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func getSetting(name string) (setting, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// registered returns every registered key sorted by name.
+func registered() []setting {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	list := make([]setting, 0, len(registry))
+	for _, s := range registry {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
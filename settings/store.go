@@ -0,0 +1,261 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gofreego/goutils/configutils"
+	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/logger"
+)
+
+const (
+	// maxUpdateAttempts is the number of read-modify-write attempts on version conflicts
+	maxUpdateAttempts = 3
+)
+
+// Config represents the configuration of the settings store.
+// Path : path of the settings document in the config reader, the document maps the key names to their values
+// Format : format of the settings document, default json
+// RefreshInterval : interval to reload the settings when the config reader does not support watching, default 30s
+type Config struct {
+	Path            string                  `yaml:"Path" required:"true"`
+	Format          common.ConfigFormatType `yaml:"Format" default:"json" choices:"json,yaml,toml"`
+	RefreshInterval time.Duration           `yaml:"RefreshInterval" default:"30s"`
+}
+
+// WithDefaults sets the default tags on the zero valued fields.
+// returns an error if a default tag is invalid
+func (c *Config) WithDefaults() error {
+	return configutils.ApplyDefaults(c)
+}
+
+// Setting is the current state of a registered key, as listed by the admin handler.
+type Setting struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     any    `json:"default"`
+	Value       any    `json:"value"`
+	IsDefault   bool   `json:"isDefault"`
+}
+
+// Store caches the settings document of a config reader in memory and keeps it up to date.
+// Changes are watched if the reader implements configutils.Watcher, consul and zookeeper, else the document is reloaded every RefreshInterval.
+type Store struct {
+	reader   configutils.ConfigReader
+	conf     *Config
+	cancel   context.CancelFunc
+	done     chan struct{}
+	mu       sync.RWMutex
+	raw      map[string]any
+	decoded  map[string]any
+	onChange []func(name string)
+}
+
+// NewStore loads the settings document and starts watching it for changes until Close is called.
+// A missing document is not an error, every key has its default value then.
+func NewStore(ctx context.Context, reader configutils.ConfigReader, conf *Config) (*Store, error) {
+	if reader == nil || conf == nil || conf.Path == "" {
+		return nil, common.ErrInvalidConfig
+	}
+	if err := conf.WithDefaults(); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		reader:  reader,
+		conf:    conf,
+		done:    make(chan struct{}),
+		raw:     map[string]any{},
+		decoded: map[string]any{},
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	ctx, s.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	go s.watch(ctx)
+	return s, nil
+}
+
+// Close stops watching the settings document.
+func (s *Store) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// OnChange registers fn to be called with the name of every key whose value changes.
+func (s *Store) OnChange(fn func(name string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// Reload reads the settings document from the config reader.
+func (s *Store) Reload(ctx context.Context) error {
+	values := map[string]any{}
+	err := s.reader.Read(ctx, s.conf.Path, &values, s.conf.Format)
+	if err != nil && !errors.Is(err, common.ErrConfigNotFound) {
+		logger.Error(ctx, "Error reading settings from path : %s, err : %v", s.conf.Path, err)
+		return err
+	}
+	s.apply(ctx, values)
+	return nil
+}
+
+// List returns the current state of every registered key, sorted by name.
+func (s *Store) List() []Setting {
+	var list []Setting
+	for _, key := range registered() {
+		value, ok := s.value(key)
+		if !ok {
+			value = key.defaultValue()
+		}
+		list = append(list, Setting{
+			Name:        key.Name(),
+			Description: key.Description(),
+			Default:     key.defaultValue(),
+			Value:       value,
+			IsDefault:   !ok,
+		})
+	}
+	return list
+}
+
+// Reset removes the value of the key from the settings document, so that the key has its default value again.
+func (s *Store) Reset(ctx context.Context, name string) error {
+	return s.modify(ctx, func(values map[string]any) {
+		delete(values, name)
+	})
+}
+
+// value returns the decoded value of the key, false if it is not set or can not be decoded.
+func (s *Store) value(key setting) (any, bool) {
+	s.mu.RLock()
+	value, ok := s.decoded[key.Name()]
+	raw, found := s.raw[key.Name()]
+	s.mu.RUnlock()
+	if ok || !found {
+		return value, ok
+	}
+
+	value, err := key.decode(raw)
+	if err != nil {
+		logger.Error(context.Background(), "Error decoding setting : %s, err : %v", key.Name(), err)
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the document may have been reloaded in between
+	if current, ok := s.raw[key.Name()]; ok && reflect.DeepEqual(current, raw) {
+		s.decoded[key.Name()] = value
+	}
+	return value, true
+}
+
+// set updates the value of the key in the settings document.
+func (s *Store) set(ctx context.Context, name string, value any) error {
+	return s.modify(ctx, func(values map[string]any) {
+		values[name] = value
+	})
+}
+
+// modify applies change to the settings document with a read-modify-write cycle.
+// It uses versioned updates if the reader supports them and retries on version conflicts.
+func (s *Store) modify(ctx context.Context, change func(values map[string]any)) error {
+	versioned, ok := s.reader.(configutils.VersionedConfigReader)
+	if !ok {
+		values := map[string]any{}
+		err := s.reader.Read(ctx, s.conf.Path, &values, s.conf.Format)
+		if err != nil && !errors.Is(err, common.ErrConfigNotFound) {
+			return err
+		}
+		change(values)
+		if err := s.reader.Update(ctx, s.conf.Path, values, s.conf.Format); err != nil {
+			logger.Error(ctx, "Error updating settings at path : %s, err : %v", s.conf.Path, err)
+			return err
+		}
+		s.apply(ctx, values)
+		return nil
+	}
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		values := map[string]any{}
+		version, err := versioned.ReadVersion(ctx, s.conf.Path, &values, s.conf.Format)
+		if err != nil && !errors.Is(err, common.ErrConfigNotFound) {
+			return err
+		}
+		change(values)
+		_, err = versioned.UpdateVersion(ctx, s.conf.Path, values, version, s.conf.Format)
+		if errors.Is(err, common.ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			logger.Error(ctx, "Error updating settings at path : %s, err : %v", s.conf.Path, err)
+			return err
+		}
+		s.apply(ctx, values)
+		return nil
+	}
+	return common.ErrVersionConflict
+}
+
+// apply replaces the cached settings document and notifies the listeners about the changed keys.
+func (s *Store) apply(ctx context.Context, values map[string]any) {
+	s.mu.Lock()
+	var changed []string
+	for name, value := range values {
+		if old, ok := s.raw[name]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range s.raw {
+		if _, ok := values[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	s.raw = values
+	s.decoded = map[string]any{}
+	listeners := s.onChange
+	s.mu.Unlock()
+
+	for _, name := range changed {
+		logger.Debug(ctx, "setting changed : %s", name)
+		for _, fn := range listeners {
+			fn(name)
+		}
+	}
+}
+
+// watch keeps the cache up to date until ctx is done.
+func (s *Store) watch(ctx context.Context) {
+	defer close(s.done)
+	if watcher, ok := s.reader.(configutils.Watcher); ok {
+		_ = watcher.Watch(ctx, s.conf.Path, func(data []byte) {
+			values := map[string]any{}
+			// a deleted document resets every key to its default value
+			if data == nil {
+				s.apply(ctx, values)
+				return
+			}
+			if err := common.Unmarshal(data, &values, s.conf.Format); err != nil {
+				logger.Error(ctx, "Error unmarshalling settings from path : %s, err : %v", s.conf.Path, err)
+				return
+			}
+			s.apply(ctx, values)
+		})
+		return
+	}
+
+	ticker := time.NewTicker(s.conf.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.Reload(ctx)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofreego/goutils/configutils"
+	"github.com/gofreego/goutils/configutils/common"
+	"github.com/gofreego/goutils/logger"
+	"github.com/gofreego/goutils/logger/logtest"
+)
+
+var (
+	testLimit = NewKey("test.limit", 10, "maximum number of items")
+	testMode  = NewKey("test.mode", "off", "")
+)
+
+// memReader is an in-memory config reader, a nil document is missing.
+type memReader struct {
+	mu       sync.Mutex
+	data     []byte
+	updates  int
+	onUpdate func()
+}
+
+func (r *memReader) Read(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return common.ErrConfigNotFound
+	}
+	return common.Unmarshal(r.data, conf, configFormat...)
+}
+
+func (r *memReader) Update(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) error {
+	data, err := common.Marshal(conf, configFormat...)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = data
+	r.updates++
+	return nil
+}
+
+// write replaces the document, as another instance of the service would.
+func (r *memReader) write(data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data == "" {
+		r.data = nil
+		return
+	}
+	r.data = []byte(data)
+}
+
+// versionedReader is a memReader with versioned updates, the first conflicts updates fail with a version conflict.
+type versionedReader struct {
+	memReader
+	version   common.Version
+	conflicts int
+}
+
+func (r *versionedReader) ReadVersion(ctx context.Context, path string, conf any, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	r.mu.Lock()
+	version := r.version
+	r.mu.Unlock()
+	return version, r.Read(ctx, path, conf, configFormat...)
+}
+
+func (r *versionedReader) UpdateVersion(ctx context.Context, path string, conf any, version common.Version, configFormat ...common.ConfigFormatType) (common.Version, error) {
+	r.mu.Lock()
+	if r.conflicts > 0 || version != r.version {
+		r.conflicts--
+		r.version++
+		r.mu.Unlock()
+		return 0, common.ErrVersionConflict
+	}
+	r.version++
+	r.mu.Unlock()
+	return version + 1, r.Update(ctx, path, conf, configFormat...)
+}
+
+func (r *versionedReader) History(ctx context.Context, path string) ([]common.HistoryEntry, error) {
+	return nil, nil
+}
+
+func (r *versionedReader) Rollback(ctx context.Context, path string, version common.Version) error {
+	return common.ErrVersionNotFound
+}
+
+// watchingReader is a memReader whose watch is fed by the changes channel.
+type watchingReader struct {
+	memReader
+	changes chan []byte
+}
+
+func (r *watchingReader) Watch(ctx context.Context, path string, onChange func(data []byte)) error {
+	for {
+		select {
+		case data := <-r.changes:
+			r.write(string(data))
+			onChange(data)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// changes records the names passed to the OnChange listeners.
+func changes(s *Store) chan string {
+	names := make(chan string, 10)
+	s.OnChange(func(name string) { names <- name })
+	return names
+}
+
+func waitChange(t *testing.T, names chan string, want string) {
+	t.Helper()
+	select {
+	case name := <-names:
+		if name != want {
+			t.Fatalf("changed setting = %s, want %s", name, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("setting %s did not change", want)
+	}
+}
+
+func newTestStore(t *testing.T, reader configutils.ConfigReader) *Store {
+	t.Helper()
+	s, err := NewStore(context.Background(), reader, &Config{Path: "/settings"})
+	if err != nil {
+		t.Fatalf("NewStore() = %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNewStore(t *testing.T) {
+	logtest.Replace(t)
+	if _, err := NewStore(context.Background(), &memReader{}, &Config{}); !errors.Is(err, common.ErrInvalidConfig) {
+		t.Fatalf("NewStore() without a path = %v, want ErrInvalidConfig", err)
+	}
+
+	// a missing document is not an error
+	s := newTestStore(t, &memReader{})
+	if got := testLimit.Get(s); got != 10 {
+		t.Fatalf("Get() = %d, want the default 10", got)
+	}
+	if got := testLimit.Get(nil); got != 10 {
+		t.Fatalf("Get() of a nil store = %d, want the default 10", got)
+	}
+}
+
+func TestStoreValues(t *testing.T) {
+	logtest.Replace(t)
+	reader := &memReader{}
+	reader.write(`{"test.limit": 25, "test.mode": 3, "unknown": true}`)
+	s := newTestStore(t, reader)
+
+	if got := testLimit.Get(s); got != 25 {
+		t.Fatalf("Get() = %d, want 25", got)
+	}
+	// a value of the wrong type falls back to the default
+	if got := testMode.Get(s); got != "off" {
+		t.Fatalf("Get() of an invalid value = %q, want the default off", got)
+	}
+	var list []Setting
+	for _, setting := range s.List() {
+		if setting.Name == testLimit.Name() || setting.Name == testMode.Name() {
+			list = append(list, setting)
+		}
+	}
+	want := []Setting{
+		{Name: "test.limit", Description: "maximum number of items", Default: 10, Value: 25, IsDefault: false},
+		{Name: "test.mode", Default: "off", Value: "off", IsDefault: true},
+	}
+	if len(list) != len(want) || list[0] != want[0] || list[1] != want[1] {
+		t.Fatalf("List() = %+v, want %+v", list, want)
+	}
+}
+
+func TestStoreSetReset(t *testing.T) {
+	logtest.Replace(t)
+	ctx := context.Background()
+	reader := &versionedReader{conflicts: 2}
+	s := newTestStore(t, reader)
+	names := changes(s)
+
+	// the version conflicts are retried
+	if err := testLimit.Set(ctx, s, 50); err != nil {
+		t.Fatalf("Set() = %v", err)
+	}
+	waitChange(t, names, "test.limit")
+	if got := testLimit.Get(s); got != 50 || reader.updates != 1 {
+		t.Fatalf("Get() = %d after %d updates, want 50 after 1", got, reader.updates)
+	}
+
+	if err := s.Reset(ctx, testLimit.Name()); err != nil {
+		t.Fatalf("Reset() = %v", err)
+	}
+	waitChange(t, names, "test.limit")
+	if got := testLimit.Get(s); got != 10 {
+		t.Fatalf("Get() after Reset() = %d, want the default 10", got)
+	}
+
+	reader.conflicts = maxUpdateAttempts
+	if err := testLimit.Set(ctx, s, 60); !errors.Is(err, common.ErrVersionConflict) {
+		t.Fatalf("Set() with conflicts = %v, want ErrVersionConflict", err)
+	}
+	if got := testLimit.Get(s); got != 10 {
+		t.Fatalf("Get() after a failed Set() = %d, want 10", got)
+	}
+}
+
+func TestStoreWatch(t *testing.T) {
+	logs := logtest.Replace(t)
+	reader := &watchingReader{changes: make(chan []byte)}
+	s := newTestStore(t, reader)
+	names := changes(s)
+
+	reader.changes <- []byte(`{"test.limit": 20, "test.mode": "on"}`)
+	got := []string{<-names, <-names}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"test.limit", "test.mode"}) || testLimit.Get(s) != 20 || testMode.Get(s) != "on" {
+		t.Fatalf("changed %v, values %d %q, want 20 and on", got, testLimit.Get(s), testMode.Get(s))
+	}
+
+	// a removed key reverts to its default value
+	reader.changes <- []byte(`{"test.mode": "on"}`)
+	waitChange(t, names, "test.limit")
+	if got := testLimit.Get(s); got != 10 {
+		t.Fatalf("Get() of a removed key = %d, want the default 10", got)
+	}
+
+	// an invalid document is ignored
+	reader.changes <- []byte(`{`)
+	// a deleted document reverts every key
+	reader.changes <- nil
+	waitChange(t, names, "test.mode")
+	if got := testMode.Get(s); got != "off" {
+		t.Fatalf("Get() of a deleted document = %q, want the default off", got)
+	}
+	logs.AssertLogged(t, logger.ErrorLevel, "Error unmarshalling settings from path : /settings")
+}
+
+func TestStoreRefresh(t *testing.T) {
+	logtest.Replace(t)
+	reader := &memReader{}
+	s, err := NewStore(context.Background(), reader, &Config{Path: "/settings", RefreshInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewStore() = %v", err)
+	}
+	t.Cleanup(s.Close)
+	names := changes(s)
+
+	reader.write(`{"test.limit": 30}`)
+	waitChange(t, names, "test.limit")
+	if got := testLimit.Get(s); got != 30 {
+		t.Fatalf("Get() = %d, want 30", got)
+	}
+	reader.write("")
+	waitChange(t, names, "test.limit")
+	if got := testLimit.Get(s); got != 10 {
+		t.Fatalf("Get() of a deleted document = %d, want the default 10", got)
+	}
+}